@@ -0,0 +1,212 @@
+package api
+
+import (
+	"archive/zip"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ihyaulhaq/go-server/internal/audit"
+	"github.com/ihyaulhaq/go-server/internal/auth"
+	"github.com/ihyaulhaq/go-server/internal/database"
+)
+
+type exportProfile struct {
+	Id                  uuid.UUID  `json:"id"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+	Email               string     `json:"email"`
+	SuspendedUntil      *time.Time `json:"suspended_until"`
+	BannedAt            *time.Time `json:"banned_at"`
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at"`
+}
+
+type exportChirp struct {
+	ID        uuid.UUID  `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	Body      string     `json:"body"`
+	DeletedAt *time.Time `json:"deleted_at"`
+}
+
+type exportSession struct {
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+}
+
+// exportSubscription is the user's current plan. Upgrades aren't stored
+// with their date, so there is no history to export.
+type exportSubscription struct {
+	Plan        string `json:"plan"`
+	IsChirpyRed bool   `json:"is_chirpy_red"`
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+func (cfg *ApiConfig) HandleExportUser(w http.ResponseWriter, r *http.Request) {
+	userIDVal := r.Context().Value(userIDContextKey)
+	userId, ok := userIDVal.(uuid.UUID)
+	if !ok {
+		respondWithError(w, r, 401, "unauthorized")
+		return
+	}
+
+	user, err := cfg.DB.GetUserByID(r.Context(), userId)
+	if err != nil {
+		respondWithAPIError(w, r, err, "user")
+		return
+	}
+
+	chirps, err := cfg.DB.ExportChirpsByAuthor(r.Context(), userId)
+	if err != nil {
+		respondWithError(w, r, 500, "something went wrong: cant get chirps")
+		return
+	}
+
+	tokens, err := cfg.DB.GetRefreshTokensByUser(r.Context(), userId)
+	if err != nil {
+		respondWithError(w, r, 500, "something went wrong: cant get sessions")
+		return
+	}
+
+	profile := exportProfile{
+		Id:                  user.ID,
+		CreatedAt:           user.CreatedAt,
+		UpdatedAt:           user.UpdatedAt,
+		Email:               user.Email,
+		SuspendedUntil:      nullTimePtr(user.SuspendedUntil),
+		BannedAt:            nullTimePtr(user.BannedAt),
+		DeletionScheduledAt: nullTimePtr(user.DeletionScheduledAt),
+	}
+
+	exportedChirps := make([]exportChirp, 0, len(chirps))
+	for _, c := range chirps {
+		exportedChirps = append(exportedChirps, exportChirp{
+			ID:        c.ID,
+			CreatedAt: c.CreatedAt,
+			UpdatedAt: c.UpdatedAt,
+			Body:      c.Body,
+			DeletedAt: nullTimePtr(c.DeletedAt),
+		})
+	}
+
+	// Token values are credentials, so sessions only carry their lifecycle.
+	sessions := make([]exportSession, 0, len(tokens))
+	for _, t := range tokens {
+		sessions = append(sessions, exportSession{
+			CreatedAt: t.CreatedAt,
+			ExpiresAt: t.ExpiresAt,
+			RevokedAt: nullTimePtr(t.RevokedAt),
+		})
+	}
+
+	plan := "free"
+	if user.IsChirpyRed {
+		plan = "chirpy_red"
+	}
+	subscription := exportSubscription{
+		Plan:        plan,
+		IsChirpyRed: user.IsChirpyRed,
+	}
+
+	files := []struct {
+		name    string
+		payload interface{}
+	}{
+		{"profile.json", profile},
+		{"chirps.json", exportedChirps},
+		{"sessions.json", sessions},
+		{"subscription.json", subscription},
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="chirpy-export.zip"`)
+	w.WriteHeader(http.StatusOK)
+
+	// Headers are already sent, so failures below can only be logged.
+	zw := zip.NewWriter(w)
+	for _, f := range files {
+		fw, err := zw.Create(f.name)
+		if err != nil {
+			requestLogger(r).Error("error creating export file", "file", f.name, "error", err)
+			return
+		}
+
+		encoder := json.NewEncoder(fw)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(f.payload); err != nil {
+			requestLogger(r).Error("error writing export file", "file", f.name, "error", err)
+			return
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		requestLogger(r).Error("error finishing export", "error", err)
+	}
+}
+
+func (cfg *ApiConfig) HandleDeleteMe(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Password string `json:"password" validate:"required"`
+	}
+
+	userIDVal := r.Context().Value(userIDContextKey)
+	userId, ok := userIDVal.(uuid.UUID)
+	if !ok {
+		respondWithError(w, r, 401, "unauthorized")
+		return
+	}
+
+	params, err := decodeJSON[parameters](w, r)
+	if err != nil {
+		respondWithAPIError(w, r, err, "")
+		return
+	}
+
+	user, err := cfg.DB.GetUserByID(r.Context(), userId)
+	if err != nil {
+		respondWithAPIError(w, r, err, "user")
+		return
+	}
+
+	match, err := auth.CheckPasswordHash(params.Password, user.HashedPassword)
+	if err != nil {
+		respondWithError(w, r, 500, "something went wrong: cant check password hash")
+		return
+	}
+
+	if !match {
+		respondWithError(w, r, 401, "unauthorized: invalid credentials")
+		return
+	}
+
+	// The store also revokes the user's refresh tokens, atomically with
+	// scheduling the deletion, so no session outlives the request.
+	deleteAt := time.Now().UTC().Add(cfg.AccountDeletionDelay)
+	user, err = cfg.DB.ScheduleUserDeletion(r.Context(), database.ScheduleUserDeletionParams{
+		ID:                  userId,
+		DeletionScheduledAt: sql.NullTime{Time: deleteAt, Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, r, 500, "something went wrong: cant schedule deletion")
+		return
+	}
+
+	cfg.recordAudit(r, userId, audit.ActionUserDelete, userId.String())
+
+	type response struct {
+		DeletionScheduledAt time.Time `json:"deletion_scheduled_at"`
+	}
+
+	respondWithJSON(w, http.StatusAccepted, response{
+		DeletionScheduledAt: user.DeletionScheduledAt.Time,
+	})
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestExportUser(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ts *testServer) {
+		alice := ts.createUser("alice@example.com")
+		ts.createChirp(alice, "hello")
+
+		resp := ts.do("GET", "/api/users/me/export", alice.bearer(), nil)
+		expectStatus(t, resp, http.StatusOK)
+
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		files := map[string]bool{}
+		for _, f := range zr.File {
+			files[f.Name] = true
+		}
+		for _, name := range []string{"profile.json", "chirps.json", "sessions.json", "subscription.json"} {
+			if !files[name] {
+				t.Errorf("expected %s in export", name)
+			}
+		}
+
+		if bytes.Contains(data, []byte(alice.RefreshToken)) {
+			t.Fatal("export must not contain refresh token values")
+		}
+	})
+}
+
+func TestDeleteMe(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ts *testServer) {
+		alice := ts.createUser("alice@example.com")
+
+		expectStatus(t, ts.do("DELETE", "/api/users/me", alice.bearer(), map[string]string{"password": "wrong"}), http.StatusUnauthorized)
+		expectStatus(t, ts.do("DELETE", "/api/users/me", alice.bearer(), map[string]string{"password": testPassword}), http.StatusAccepted)
+
+		// The account is locked but logging in again cancels the deletion.
+		expectStatus(t, ts.do("GET", "/api/users/me/export", alice.bearer(), nil), http.StatusForbidden)
+		expectStatus(t, ts.do("POST", "/api/refresh", "Bearer "+alice.RefreshToken, nil), http.StatusUnauthorized)
+		alice = ts.login(alice.Email)
+		expectStatus(t, ts.do("GET", "/api/users/me/export", alice.bearer(), nil), http.StatusOK)
+	})
+}
+
+func TestDeleteMe_RefusedLoginKeepsDeletion(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ts *testServer) {
+		alice := ts.createUser("alice@example.com")
+
+		expectStatus(t, ts.do("DELETE", "/api/users/me", alice.bearer(), map[string]string{"password": testPassword}), http.StatusAccepted)
+		expectStatus(t, ts.do("POST", "/admin/users/"+alice.ID.String()+"/ban", "ApiKey "+testAdminKey, map[string]string{"reason": "abuse"}), http.StatusOK)
+
+		resp := ts.do("POST", "/api/login", "", map[string]string{
+			"email":    alice.Email,
+			"password": testPassword,
+		})
+		expectStatus(t, resp, http.StatusForbidden)
+
+		user, err := ts.store.GetUserByID(context.Background(), alice.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !user.DeletionScheduledAt.Valid {
+			t.Fatal("expected a refused login to leave the deletion scheduled")
+		}
+	})
+}
+
+func TestPurgeScheduledUserDeletions(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ts *testServer) {
+		ts.cfg.AccountDeletionDelay = 0
+		alice := ts.createUser("alice@example.com")
+		ts.createChirp(alice, "hello")
+
+		expectStatus(t, ts.do("DELETE", "/api/users/me", alice.bearer(), map[string]string{"password": testPassword}), http.StatusAccepted)
+
+		ts.cfg.purgeScheduledUserDeletions(context.Background())
+
+		if _, err := ts.store.GetUserByID(context.Background(), alice.ID); err == nil {
+			t.Fatal("expected user to be purged")
+		}
+		if chirps := ts.listChirps("/api/chirps", ""); len(chirps) != 0 {
+			t.Fatalf("expected chirps to be removed with the user, got %d", len(chirps))
+		}
+	})
+}
@@ -0,0 +1,238 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ihyaulhaq/go-server/internal/audit"
+	"github.com/ihyaulhaq/go-server/internal/database"
+	"github.com/ihyaulhaq/go-server/internal/health"
+)
+
+func TestHealthEndpoints(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ts *testServer) {
+
+		expectStatus(t, ts.do("GET", "/api/healthz", "", nil), http.StatusOK)
+		expectStatus(t, ts.do("GET", "/api/livez", "", nil), http.StatusOK)
+		expectStatus(t, ts.do("GET", "/api/readyz", "", nil), http.StatusOK)
+
+		ts.cfg.HealthCheckers = []health.Checker{
+			health.NewChecker("database", func(ctx context.Context) error { return errors.New("dial tcp db.internal:5432: refused") }),
+		}
+		resp := ts.do("GET", "/api/readyz", "", nil)
+		expectStatus(t, resp, http.StatusServiceUnavailable)
+		var report health.Report
+		decodeBody(t, resp, &report)
+		if got := report.Components["database"].Error; got != health.ErrorUnavailable {
+			t.Fatalf("expected the cause to stay out of the public report, got %q", got)
+		}
+
+		ts.cfg.HealthCheckers = nil
+		ts.cfg.StartDraining()
+		expectStatus(t, ts.do("GET", "/api/readyz", "", nil), http.StatusServiceUnavailable)
+		expectStatus(t, ts.do("GET", "/api/livez", "", nil), http.StatusOK)
+	})
+}
+
+func TestMetricsEndpoints(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ts *testServer) {
+		alice := ts.createUser("alice@example.com")
+		expectStatus(t, ts.do("POST", "/api/login", "", map[string]string{
+			"email":    alice.Email,
+			"password": "wrong",
+		}), http.StatusUnauthorized)
+
+		resp := ts.do("GET", "/admin/metrics", "", nil)
+		expectStatus(t, resp, http.StatusOK)
+		if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+			t.Fatalf("expected html, got %q", ct)
+		}
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Failed attempts must not be counted as usage.
+		if !strings.Contains(string(body), "Successful logins: 1") || !strings.Contains(string(body), "Failed logins: 1") {
+			t.Fatalf("expected logins by result, got:\n%s", body)
+		}
+
+		resp = ts.do("GET", "/metrics", "", nil)
+		expectStatus(t, resp, http.StatusOK)
+
+		if got := ts.cfg.Metrics.CounterValue("chirpy_logins_total"); got != 2 {
+			t.Fatalf("expected 2 login attempts, got %v", got)
+		}
+	})
+}
+
+func TestReset(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ts *testServer) {
+		alice := ts.createUser("alice@example.com")
+		ts.createChirp(alice, "hello")
+
+		expectStatus(t, ts.do("POST", "/admin/reset", "", nil), http.StatusOK)
+
+		if chirps := ts.listChirps("/api/chirps", ""); len(chirps) != 0 {
+			t.Fatalf("expected no chirps after reset, got %d", len(chirps))
+		}
+		expectStatus(t, ts.do("POST", "/api/login", "", map[string]string{
+			"email":    alice.Email,
+			"password": testPassword,
+		}), http.StatusNotFound)
+
+		expectStatus(t, ts.do("POST", "/api/refresh", "Bearer "+alice.RefreshToken, nil), http.StatusUnauthorized)
+
+		ts.cfg.Platform = "prod"
+		expectStatus(t, ts.do("POST", "/admin/reset", "", nil), http.StatusForbidden)
+	})
+}
+
+func TestSeed(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ts *testServer) {
+		ts.createUser("leftover@example.com")
+
+		// Seeding twice must produce the same data set.
+		for range 2 {
+			expectStatus(t, ts.do("POST", "/admin/seed", "", nil), http.StatusOK)
+		}
+
+		if chirps := ts.listChirps("/api/chirps", ""); len(chirps) != 4 {
+			t.Fatalf("expected 4 seeded chirps, got %d", len(chirps))
+		}
+
+		expectStatus(t, ts.do("POST", "/api/login", "", map[string]string{
+			"email":    "leftover@example.com",
+			"password": testPassword,
+		}), http.StatusNotFound)
+
+		resp := ts.do("POST", "/api/login", "", map[string]string{
+			"email":    "carol@example.com",
+			"password": "carol-password",
+		})
+		expectStatus(t, resp, http.StatusOK)
+		var carol UserLoginResponse
+		decodeBody(t, resp, &carol)
+
+		// Carol blocks bob in the fixture.
+		if chirps := ts.listChirps("/api/chirps", "Bearer "+carol.Token); len(chirps) != 3 {
+			t.Fatalf("expected 3 chirps for carol, got %d", len(chirps))
+		}
+
+		expectStatus(t, ts.do("POST", "/api/refresh", "Bearer seed-refresh-token-alice", nil), http.StatusOK)
+
+		ts.cfg.Platform = "prod"
+		expectStatus(t, ts.do("POST", "/admin/seed", "", nil), http.StatusForbidden)
+	})
+}
+
+func TestSeed_RollsBackOnError(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ts *testServer) {
+		alice := ts.createUser("alice@example.com")
+
+		// The fixture references a chirp author that doesn't exist.
+		path := filepath.Join(t.TempDir(), "seed.json")
+		fixture := `{"chirps": [{"id": "00000000-0000-4000-9000-000000000001", "user_id": "00000000-0000-4000-8000-00000000ffff", "body": "orphan"}]}`
+		if err := os.WriteFile(path, []byte(fixture), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		ts.cfg.SeedFile = path
+
+		expectStatus(t, ts.do("POST", "/admin/seed", "", nil), http.StatusInternalServerError)
+
+		// The reset that ran first in the transaction was rolled back.
+		ts.login(alice.Email)
+	})
+}
+
+func TestWithTx_Nested(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ts *testServer) {
+		ctx := context.Background()
+		alice := ts.createUser("alice@example.com")
+		errAbort := errors.New("abort")
+
+		// A nested WithTx joins the outer transaction: it must not wait on
+		// it, and its writes roll back with it.
+		done := make(chan error, 1)
+		go func() {
+			done <- ts.store.WithTx(ctx, func(tx database.Store) error {
+				err := tx.WithTx(ctx, func(tx database.Store) error {
+					return resetData(ctx, tx)
+				})
+				if err != nil {
+					return err
+				}
+				return errAbort
+			})
+		}()
+
+		select {
+		case err := <-done:
+			if !errors.Is(err, errAbort) {
+				t.Fatalf("expected the outer error, got %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("nested WithTx did not return")
+		}
+
+		if _, err := ts.store.GetUserByID(ctx, alice.ID); err != nil {
+			t.Fatalf("expected the nested reset to be rolled back: %v", err)
+		}
+	})
+}
+
+func TestListAuditEvents(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ts *testServer) {
+		alice := ts.createUser("alice@example.com")
+		ts.login(alice.Email)
+		admin := "ApiKey " + testAdminKey
+
+		type page struct {
+			Events     []AuditEventResponse `json:"events"`
+			NextOffset *int32               `json:"next_offset"`
+		}
+
+		resp := ts.do("GET", "/admin/audit?action="+audit.ActionLogin, admin, nil)
+		expectStatus(t, resp, http.StatusOK)
+
+		var got page
+		decodeBody(t, resp, &got)
+		if len(got.Events) != 2 {
+			t.Fatalf("expected 2 login events, got %d", len(got.Events))
+		}
+		if got.Events[0].ID < got.Events[1].ID {
+			t.Fatal("expected newest events first")
+		}
+		if got.Events[0].ActorID == nil || *got.Events[0].ActorID != alice.ID {
+			t.Fatalf("unexpected actor %v", got.Events[0].ActorID)
+		}
+
+		resp = ts.do("GET", "/admin/audit?action="+audit.ActionLogin+"&limit=1", admin, nil)
+		expectStatus(t, resp, http.StatusOK)
+		got = page{}
+		decodeBody(t, resp, &got)
+		if len(got.Events) != 1 || got.NextOffset == nil || *got.NextOffset != 1 {
+			t.Fatalf("unexpected page %+v", got)
+		}
+
+		expectStatus(t, ts.do("GET", "/admin/audit?limit=0", admin, nil), http.StatusBadRequest)
+		expectStatus(t, ts.do("GET", "/admin/audit?offset=2147483648", admin, nil), http.StatusBadRequest)
+		expectStatus(t, ts.do("GET", "/admin/audit?since=yesterday", admin, nil), http.StatusBadRequest)
+	})
+}
+
+func TestStaticFiles(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ts *testServer) {
+
+		expectStatus(t, ts.do("GET", "/app/", "", nil), http.StatusOK)
+		if got := ts.cfg.Metrics.CounterValue("chirpy_fileserver_hits_total"); got != 1 {
+			t.Fatalf("expected 1 fileserver hit, got %v", got)
+		}
+	})
+}
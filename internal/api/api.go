@@ -2,7 +2,7 @@ package api
 
 import (
 	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
 	"strings"
 )
@@ -28,15 +28,14 @@ func replaceBadWords(w string) string {
 	return strings.Join(words, " ")
 }
 
-func respondWithError(w http.ResponseWriter, code int, msg string) {
-	type errors struct {
-		Error string `json:"error"`
-	}
-	log.Printf("Responding with error %d: %s", code, msg)
-
-	respondWithJSON(w, code, errors{
-		Error: msg,
-	})
+// respondWithError writes a problem response with the default code for
+// status. For 5xx responses msg is logged but replaced by a generic detail.
+func respondWithError(w http.ResponseWriter, r *http.Request, code int, msg string) {
+	respondWithAPIError(w, r, &APIError{
+		Status: code,
+		Code:   codeForStatus(code),
+		Detail: msg,
+	}, "")
 }
 
 func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
@@ -45,7 +44,7 @@ func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 
 	data, err := json.Marshal(payload)
 	if err != nil {
-		log.Printf("Error marshalling JSON: %s", err)
+		slog.Error("error marshalling JSON", "error", err)
 		w.WriteHeader(500)
 		return
 	}
@@ -57,3 +56,38 @@ func (cfg *ApiConfig) ProtectedFunc(
 ) http.Handler {
 	return cfg.MiddlewareAuth(http.HandlerFunc(handler))
 }
+
+// statusRecorder captures the status code and body size written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (rec *statusRecorder) WriteHeader(code int) {
+	if rec.status == 0 {
+		rec.status = code
+	}
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+func (rec *statusRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	n, err := rec.ResponseWriter.Write(b)
+	rec.bytes += n
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+func (rec *statusRecorder) Status() int {
+	if rec.status == 0 {
+		return http.StatusOK
+	}
+	return rec.status
+}
@@ -0,0 +1,235 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ihyaulhaq/go-server/internal/audit"
+	"github.com/ihyaulhaq/go-server/internal/database"
+	"github.com/ihyaulhaq/go-server/internal/database/memstore"
+	"github.com/ihyaulhaq/go-server/internal/database/sqlitedb"
+	"github.com/ihyaulhaq/go-server/internal/metrics"
+	"github.com/ihyaulhaq/go-server/internal/migrate"
+	"github.com/ihyaulhaq/go-server/internal/pubsub"
+)
+
+const (
+	testSecret   = "test-secret"
+	testPolkaKey = "test-polka-key"
+	testAdminKey = "test-admin-key"
+	testPassword = "hunter22"
+)
+
+// syncAuditor writes events straight to the store so tests can assert on
+// them without waiting for a background worker.
+type syncAuditor struct {
+	writer audit.Writer
+}
+
+func (a syncAuditor) Record(ctx context.Context, event audit.Event) {
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now().UTC()
+	}
+	_ = a.writer.WriteEvent(ctx, event)
+}
+
+type testServer struct {
+	t      *testing.T
+	cfg    *ApiConfig
+	store  database.Store
+	server *httptest.Server
+}
+
+// storeBackends lists every database.Store implementation the handler suite
+// runs against.
+var storeBackends = []struct {
+	name string
+	open func(t *testing.T) database.Store
+}{
+	{"memory", func(t *testing.T) database.Store { return memstore.New() }},
+	{"sqlite", openSQLiteStore},
+}
+
+func openSQLiteStore(t *testing.T) database.Store {
+	t.Helper()
+
+	db, err := sqlitedb.Open(filepath.Join(t.TempDir(), "chirpy.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err := migrate.Up(context.Background(), db, database.DriverSQLite); err != nil {
+		t.Fatalf("migrating sqlite: %v", err)
+	}
+
+	return sqlitedb.NewStore(db)
+}
+
+// forEachStore runs fn as a subtest against a fresh server for each backend.
+func forEachStore(t *testing.T, fn func(t *testing.T, ts *testServer)) {
+	for _, backend := range storeBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			fn(t, newTestServer(t, backend.open(t)))
+		})
+	}
+}
+
+func newTestServer(t *testing.T, store database.Store) *testServer {
+	t.Helper()
+
+	cfg := &ApiConfig{
+		Metrics:   metrics.New(nil),
+		DB:        store,
+		Platform:  "dev",
+		SecretKey: testSecret,
+		PolkaKey:  testPolkaKey,
+		AdminKey:  testAdminKey,
+		Auditor:   syncAuditor{writer: audit.DBWriter{DB: store}},
+
+		ChirpRestoreWindow:   time.Hour,
+		ChirpRetention:       24 * time.Hour,
+		AccountDeletionDelay: time.Hour,
+		IdempotencyTTL:       time.Hour,
+		HealthCheckTimeout:   time.Second,
+		EventHub:             pubsub.NewHub(100),
+	}
+	cfg.Events = cfg.EventHub
+
+	site, err := NewStaticSite(testSiteFS(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(MiddlewareLogging(cfg.MiddlewareInstrument(RecordRoute(cfg.Routes(site)))))
+	t.Cleanup(server.Close)
+	// Cleanups run last first, so open streams end before server.Close
+	// waits for them.
+	t.Cleanup(cfg.EventHub.Close)
+
+	return &testServer{t: t, cfg: cfg, store: store, server: server}
+}
+
+// do sends a request with an optional JSON body and "Authorization" value.
+func (ts *testServer) do(method, path, authorization string, body any) *http.Response {
+	ts.t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			ts.t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, ts.server.URL+path, reader)
+	if err != nil {
+		ts.t.Fatal(err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	resp, err := ts.server.Client().Do(req)
+	if err != nil {
+		ts.t.Fatal(err)
+	}
+	ts.t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+type testUser struct {
+	ID           uuid.UUID
+	Email        string
+	Token        string
+	RefreshToken string
+}
+
+func (u testUser) bearer() string {
+	return "Bearer " + u.Token
+}
+
+// createUser registers and logs in a user with testPassword.
+func (ts *testServer) createUser(email string) testUser {
+	ts.t.Helper()
+
+	resp := ts.do("POST", "/api/users", "", map[string]string{
+		"email":    email,
+		"password": testPassword,
+	})
+	expectStatus(ts.t, resp, http.StatusCreated)
+
+	return ts.login(email)
+}
+
+func (ts *testServer) login(email string) testUser {
+	ts.t.Helper()
+
+	resp := ts.do("POST", "/api/login", "", map[string]string{
+		"email":    email,
+		"password": testPassword,
+	})
+	expectStatus(ts.t, resp, http.StatusOK)
+
+	var login UserLoginResponse
+	decodeBody(ts.t, resp, &login)
+
+	return testUser{
+		ID:           login.Id,
+		Email:        login.Email,
+		Token:        login.Token,
+		RefreshToken: login.RefreshToken,
+	}
+}
+
+func (ts *testServer) createChirp(user testUser, body string) ChirpsResponse {
+	ts.t.Helper()
+
+	resp := ts.do("POST", "/api/chirps", user.bearer(), map[string]string{"body": body})
+	expectStatus(ts.t, resp, http.StatusCreated)
+
+	var chirp ChirpsResponse
+	decodeBody(ts.t, resp, &chirp)
+	return chirp
+}
+
+func (ts *testServer) listChirps(path, authorization string) []ChirpsResponse {
+	ts.t.Helper()
+
+	resp := ts.do("GET", path, authorization, nil)
+	expectStatus(ts.t, resp, http.StatusOK)
+
+	var chirps []ChirpsResponse
+	decodeBody(ts.t, resp, &chirps)
+	return chirps
+}
+
+func expectStatus(t *testing.T, resp *http.Response, want int) {
+	t.Helper()
+
+	if resp.StatusCode != want {
+		body, _ := io.ReadAll(resp.Body)
+		t.Fatalf("%s %s: expected status %d, got %d: %s",
+			resp.Request.Method, resp.Request.URL.Path, want, resp.StatusCode, body)
+	}
+}
+
+func decodeBody(t *testing.T, resp *http.Response, dest any) {
+	t.Helper()
+
+	if err := json.NewDecoder(resp.Body).Decode(dest); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+}
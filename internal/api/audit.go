@@ -0,0 +1,145 @@
+package api
+
+import (
+	"database/sql"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ihyaulhaq/go-server/internal/audit"
+	"github.com/ihyaulhaq/go-server/internal/database"
+)
+
+type AuditEventResponse struct {
+	ID        int64      `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	ActorID   *uuid.UUID `json:"actor_id"`
+	Action    string     `json:"action"`
+	Target    string     `json:"target"`
+	IP        string     `json:"ip"`
+	UserAgent string     `json:"user_agent"`
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// recordAudit hands an event to the configured auditor. actorID may be
+// uuid.Nil for anonymous callers.
+func (cfg *ApiConfig) recordAudit(r *http.Request, actorID uuid.UUID, action, target string) {
+	if cfg.Auditor == nil {
+		return
+	}
+
+	cfg.Auditor.Record(r.Context(), audit.Event{
+		ActorID:   actorID,
+		Action:    action,
+		Target:    target,
+		IP:        clientIP(r),
+		UserAgent: r.UserAgent(),
+	})
+}
+
+func (cfg *ApiConfig) HandleListAuditEvents(w http.ResponseWriter, r *http.Request) {
+	const defaultLimit = 50
+	const maxLimit = 500
+
+	query := r.URL.Query()
+	params := database.ListAuditEventsParams{
+		Limit: defaultLimit,
+	}
+
+	if actorIdStr := query.Get("actor_id"); actorIdStr != "" {
+		actorId, err := parseUUIDParam("actor_id", actorIdStr)
+		if err != nil {
+			respondWithAPIError(w, r, err, "")
+			return
+		}
+		params.ActorID = uuid.NullUUID{UUID: actorId, Valid: true}
+	}
+
+	if action := query.Get("action"); action != "" {
+		params.Action = sql.NullString{String: action, Valid: true}
+	}
+
+	for _, bound := range []struct {
+		key  string
+		dest *sql.NullTime
+	}{
+		{"since", &params.Since},
+		{"until", &params.Until},
+	} {
+		val := query.Get(bound.key)
+		if val == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, val)
+		if err != nil {
+			respondWithError(w, r, 400, "invalid "+bound.key+": must be RFC 3339")
+			return
+		}
+		*bound.dest = sql.NullTime{Time: t, Valid: true}
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.ParseInt(limitStr, 10, 32)
+		if err != nil || limit < 1 || limit > maxLimit {
+			respondWithError(w, r, 400, "invalid limit")
+			return
+		}
+		params.Limit = int32(limit)
+	}
+
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		// ParseInt rejects offsets that don't fit the query's int32.
+		offset, err := strconv.ParseInt(offsetStr, 10, 32)
+		if err != nil || offset < 0 {
+			respondWithError(w, r, 400, "invalid offset")
+			return
+		}
+		params.Offset = int32(offset)
+	}
+
+	events, err := cfg.DB.ListAuditEvents(r.Context(), params)
+	if err != nil {
+		respondWithError(w, r, 500, "something went wrong: cant list audit events")
+		return
+	}
+
+	type response struct {
+		Events     []AuditEventResponse `json:"events"`
+		NextOffset *int32               `json:"next_offset"`
+	}
+
+	resp := response{
+		Events: make([]AuditEventResponse, 0, len(events)),
+	}
+	for _, e := range events {
+		event := AuditEventResponse{
+			ID:        e.ID,
+			CreatedAt: e.CreatedAt,
+			Action:    e.Action,
+			Target:    e.Target,
+			IP:        e.Ip,
+			UserAgent: e.UserAgent,
+		}
+		if e.ActorID.Valid {
+			event.ActorID = &e.ActorID.UUID
+		}
+		resp.Events = append(resp.Events, event)
+	}
+
+	if int32(len(events)) == params.Limit && params.Offset <= math.MaxInt32-params.Limit {
+		next := params.Offset + params.Limit
+		resp.NextOffset = &next
+	}
+
+	respondWithJSON(w, 200, resp)
+}
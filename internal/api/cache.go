@@ -0,0 +1,114 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ihyaulhaq/go-server/internal/database"
+)
+
+// Cache-Control values for chirp reads. Responses may be stored but must be
+// revalidated every time, which is cheap thanks to the validators below.
+// Personalised responses (a viewer's mutes and blocks applied) must not be
+// kept by shared caches.
+const (
+	cacheControlPublic  = "public, no-cache"
+	cacheControlPrivate = "private, no-cache"
+)
+
+// chirpETag is a strong validator for a single chirp. A chirp's body and
+// author never change, so its ID and updated_at identify the representation.
+func chirpETag(c database.Chirp) string {
+	return `"` + c.ID.String() + "-" + strconv.FormatInt(c.UpdatedAt.UnixNano(), 36) + `"`
+}
+
+// chirpsETag is a strong validator for a list of chirps in the order they
+// are served. Chirps appearing, disappearing or being reordered all change
+// it, which a maximum updated_at would miss.
+func chirpsETag(chirps []ChirpsResponse) string {
+	h := sha256.New()
+	var buf [8]byte
+	for _, c := range chirps {
+		h.Write(c.ID[:])
+		binary.BigEndian.PutUint64(buf[:], uint64(c.UpdatedAt.UnixNano()))
+		h.Write(buf[:])
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// etagMatches reports whether etag appears in header, a comma-separated
+// If-Match or If-None-Match value. Weak comparison ignores the W/ prefix;
+// strong comparison never matches a weak tag.
+func etagMatches(header, etag string, weak bool) bool {
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	if weak {
+		etag = strings.TrimPrefix(etag, "W/")
+	} else if strings.HasPrefix(etag, "W/") {
+		return false
+	}
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if weak {
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// notModified evaluates If-None-Match and If-Modified-Since against the
+// current validators. As in RFC 9110, If-Modified-Since is ignored when
+// If-None-Match is present.
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return etagMatches(inm, etag, true)
+	}
+
+	ims := r.Header.Get("If-Modified-Since")
+	if ims == "" || lastModified.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+	return !lastModified.Truncate(time.Second).After(since)
+}
+
+// respondWithCacheableJSON writes payload with its validators and
+// cacheControl, or a bare 304 if the client's copy is still current. A zero
+// lastModified omits Last-Modified.
+func respondWithCacheableJSON(w http.ResponseWriter, r *http.Request, payload any, etag string, lastModified time.Time, cacheControl string) {
+	h := w.Header()
+	h.Set("Cache-Control", cacheControl)
+	h.Set("ETag", etag)
+	if !lastModified.IsZero() {
+		h.Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if notModified(r, etag, lastModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, payload)
+}
+
+// preconditionFailed is returned when an If-Match header no longer matches
+// the resource being modified.
+func preconditionFailed() *APIError {
+	return &APIError{
+		Status: http.StatusPreconditionFailed,
+		Code:   CodePreconditionFailed,
+		Detail: "resource has been modified; fetch it again and retry",
+	}
+}
@@ -0,0 +1,175 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ihyaulhaq/go-server/internal/database"
+)
+
+// doWithHeaders is ts.do for body-less requests that need extra headers.
+func (ts *testServer) doWithHeaders(method, path, authorization string, headers map[string]string) *http.Response {
+	ts.t.Helper()
+
+	req, err := http.NewRequest(method, ts.server.URL+path, nil)
+	if err != nil {
+		ts.t.Fatal(err)
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := ts.server.Client().Do(req)
+	if err != nil {
+		ts.t.Fatal(err)
+	}
+	ts.t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestGetChirp_ConditionalRequests(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ts *testServer) {
+		alice := ts.createUser("alice@example.com")
+		chirp := ts.createChirp(alice, "hello")
+		path := "/api/chirps/" + chirp.ID.String()
+
+		resp := ts.do("GET", path, "", nil)
+		expectStatus(t, resp, http.StatusOK)
+		etag := resp.Header.Get("ETag")
+		lastModified := resp.Header.Get("Last-Modified")
+		if etag == "" || etag[0] != '"' || lastModified == "" {
+			t.Fatalf("expected strong ETag and Last-Modified, got %q and %q", etag, lastModified)
+		}
+		if resp.Header.Get("Cache-Control") != cacheControlPublic || resp.Header.Get("Vary") != "Authorization" {
+			t.Fatalf("unexpected caching headers %v", resp.Header)
+		}
+
+		// Blocks apply to the viewer, so their copy stays private.
+		resp = ts.do("GET", path, alice.bearer(), nil)
+		expectStatus(t, resp, http.StatusOK)
+		if got := resp.Header.Get("Cache-Control"); got != cacheControlPrivate {
+			t.Fatalf("expected Cache-Control %q, got %q", cacheControlPrivate, got)
+		}
+
+		resp = ts.doWithHeaders("GET", path, "", map[string]string{"If-None-Match": `"other", ` + etag})
+		expectStatus(t, resp, http.StatusNotModified)
+		if resp.Header.Get("ETag") != etag {
+			t.Fatalf("304 must repeat the ETag, got %q", resp.Header.Get("ETag"))
+		}
+
+		resp = ts.doWithHeaders("GET", path, "", map[string]string{"If-None-Match": "W/" + etag})
+		expectStatus(t, resp, http.StatusNotModified)
+
+		resp = ts.doWithHeaders("GET", path, "", map[string]string{"If-Modified-Since": lastModified})
+		expectStatus(t, resp, http.StatusNotModified)
+
+		// If-None-Match wins over If-Modified-Since.
+		resp = ts.doWithHeaders("GET", path, "", map[string]string{
+			"If-None-Match":     `"stale"`,
+			"If-Modified-Since": lastModified,
+		})
+		expectStatus(t, resp, http.StatusOK)
+
+		earlier := chirp.UpdatedAt.Add(-time.Hour).UTC().Format(http.TimeFormat)
+		resp = ts.doWithHeaders("GET", path, "", map[string]string{"If-Modified-Since": earlier})
+		expectStatus(t, resp, http.StatusOK)
+	})
+}
+
+func TestGetChirps_ConditionalRequests(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ts *testServer) {
+		alice := ts.createUser("alice@example.com")
+		bob := ts.createUser("bob@example.com")
+		ts.createChirp(alice, "first")
+		ts.createChirp(bob, "second")
+
+		resp := ts.do("GET", "/api/chirps", "", nil)
+		expectStatus(t, resp, http.StatusOK)
+		etag := resp.Header.Get("ETag")
+		if resp.Header.Get("Cache-Control") != cacheControlPublic || resp.Header.Get("Vary") != "Authorization" {
+			t.Fatalf("unexpected caching headers %v", resp.Header)
+		}
+		if resp.Header.Get("Last-Modified") != "" {
+			t.Fatal("lists must not carry Last-Modified")
+		}
+
+		resp = ts.doWithHeaders("GET", "/api/chirps", "", map[string]string{"If-None-Match": etag})
+		expectStatus(t, resp, http.StatusNotModified)
+
+		resp = ts.do("GET", "/api/chirps?sort=desc", "", nil)
+		if resp.Header.Get("ETag") == etag {
+			t.Fatal("reordering the list must change its ETag")
+		}
+
+		resp = ts.do("GET", "/api/chirps", alice.bearer(), nil)
+		if got := resp.Header.Get("Cache-Control"); got != cacheControlPrivate {
+			t.Fatalf("expected personalised list to be private, got %q", got)
+		}
+
+		ts.createChirp(alice, "third")
+		resp = ts.doWithHeaders("GET", "/api/chirps", "", map[string]string{"If-None-Match": etag})
+		expectStatus(t, resp, http.StatusOK)
+	})
+}
+
+func TestDeleteChirp_IfMatch(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ts *testServer) {
+		alice := ts.createUser("alice@example.com")
+		bob := ts.createUser("bob@example.com")
+		chirp := ts.createChirp(alice, "hello")
+		path := "/api/chirps/" + chirp.ID.String()
+
+		etag := ts.do("GET", path, "", nil).Header.Get("ETag")
+
+		resp := ts.doWithHeaders("DELETE", path, alice.bearer(), map[string]string{"If-Match": `"stale"`})
+		expectProblem(t, resp, http.StatusPreconditionFailed, CodePreconditionFailed)
+
+		resp = ts.doWithHeaders("DELETE", path, alice.bearer(), map[string]string{"If-Match": "W/" + etag})
+		expectProblem(t, resp, http.StatusPreconditionFailed, CodePreconditionFailed)
+
+		resp = ts.doWithHeaders("DELETE", path, bob.bearer(), map[string]string{"If-Match": etag})
+		expectStatus(t, resp, http.StatusNotFound)
+
+		resp = ts.doWithHeaders("DELETE", path, alice.bearer(), map[string]string{"If-Match": etag})
+		expectStatus(t, resp, http.StatusOK)
+
+		resp = ts.doWithHeaders("DELETE", path, alice.bearer(), map[string]string{"If-Match": etag})
+		expectProblem(t, resp, http.StatusPreconditionFailed, CodePreconditionFailed)
+	})
+}
+
+func TestDeleteChirp_RechecksUpdatedAt(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ts *testServer) {
+		alice := ts.createUser("alice@example.com")
+		chirp := ts.createChirp(alice, "hello")
+
+		stored, err := ts.cfg.DB.GetChirp(context.Background(), chirp.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rows, err := ts.cfg.DB.DeleteChirp(context.Background(), database.DeleteChirpParams{
+			ID:        chirp.ID,
+			UserID:    alice.ID,
+			UpdatedAt: sql.NullTime{Time: stored.UpdatedAt.Add(-time.Millisecond), Valid: true},
+		})
+		if err != nil || rows != 0 {
+			t.Fatalf("expected a stale updated_at to delete nothing, got %d, %v", rows, err)
+		}
+
+		rows, err = ts.cfg.DB.DeleteChirp(context.Background(), database.DeleteChirpParams{
+			ID:        chirp.ID,
+			UserID:    alice.ID,
+			UpdatedAt: sql.NullTime{Time: stored.UpdatedAt, Valid: true},
+		})
+		if err != nil || rows != 1 {
+			t.Fatalf("expected the current updated_at to delete the chirp, got %d, %v", rows, err)
+		}
+	})
+}
@@ -1,14 +1,16 @@
 package api
 
 import (
-	"encoding/json"
+	"database/sql"
+	"errors"
 	"net/http"
-	"sort"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/ihyaulhaq/go-server/internal/database"
+	"github.com/ihyaulhaq/go-server/internal/pubsub"
 )
 
 type ChirpsResponse struct {
@@ -21,27 +23,19 @@ type ChirpsResponse struct {
 
 func (cfg *ApiConfig) HandleCreateChirps(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
-		Body string `json:"body"`
+		Body string `json:"body" validate:"required,max=140"`
 	}
 
-	decoder := json.NewDecoder(r.Body)
-	params := parameters{}
-	err := decoder.Decode(&params)
-
+	params, err := decodeJSON[parameters](w, r)
 	if err != nil {
-		respondWithError(w, 400, "invalid request payload")
-		return
-	}
-
-	if len(params.Body) > 140 {
-		respondWithError(w, 400, "Chirp is too long")
+		respondWithAPIError(w, r, err, "")
 		return
 	}
 
 	userIDVal := r.Context().Value(userIDContextKey)
 	userID, ok := userIDVal.(uuid.UUID)
 	if !ok {
-		respondWithError(w, 401, "unauthorized")
+		respondWithError(w, r, 401, "unauthorized")
 		return
 	}
 
@@ -54,10 +48,12 @@ func (cfg *ApiConfig) HandleCreateChirps(w http.ResponseWriter, r *http.Request)
 	})
 
 	if err != nil {
-		respondWithError(w, 500, err.Error())
+		respondWithAPIError(w, r, err, "chirp")
 		return
 	}
 
+	cfg.Metrics.IncChirpsCreated()
+
 	responseChirp := ChirpsResponse{
 		ID:        chirp.ID,
 		CreatedAt: chirp.CreatedAt,
@@ -65,32 +61,44 @@ func (cfg *ApiConfig) HandleCreateChirps(w http.ResponseWriter, r *http.Request)
 		Body:      chirp.Body,
 		UserID:    chirp.UserID,
 	}
+	cfg.publishChirpEvent(r, pubsub.TypeChirpCreated, chirp.CreatedAt, chirp.ID, chirp.UserID, responseChirp)
 	respondWithJSON(w, 201, responseChirp)
 }
 
 func (cfg *ApiConfig) HandleGetChirps(w http.ResponseWriter, r *http.Request) {
 	authorIdStr := r.URL.Query().Get("author_id")
 	sortBy := r.URL.Query().Get("sort")
+	viewerId, hasViewer := cfg.viewerID(r)
 
 	var chirps []database.Chirp
 	var err error
 
 	if authorIdStr != "" {
 
-		authorId, err := uuid.Parse(authorIdStr)
-		if err != nil {
-			respondWithError(w, 400, err.Error())
+		authorId, parseErr := parseUUIDParam("author_id", authorIdStr)
+		if parseErr != nil {
+			respondWithAPIError(w, r, parseErr, "")
 			return
 		}
 
-		chirps, err = cfg.DB.GetChirpByAuthor(r.Context(), authorId)
+		if hasViewer {
+			chirps, err = cfg.DB.GetChirpByAuthorForViewer(r.Context(), database.GetChirpByAuthorForViewerParams{
+				UserID:  authorId,
+				MuterID: viewerId,
+			})
+		} else {
+			chirps, err = cfg.DB.GetChirpByAuthor(r.Context(), authorId)
+		}
+	} else if hasViewer {
+
+		chirps, err = cfg.DB.GetChirpsForViewer(r.Context(), viewerId)
 	} else {
 
 		chirps, err = cfg.DB.GetChirps(r.Context())
 	}
 
 	if err != nil {
-		respondWithError(w, 500, err.Error())
+		respondWithAPIError(w, r, err, "chirp")
 		return
 	}
 
@@ -105,66 +113,165 @@ func (cfg *ApiConfig) HandleGetChirps(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 
+	// The store returns chirps oldest first with a stable tiebreak, so
+	// reversing keeps chirps created in the same instant in a fixed order.
 	if strings.ToLower(sortBy) == "desc" {
-
-		sort.Slice(
-			response,
-			func(i, j int) bool {
-				return response[i].CreatedAt.After(response[j].CreatedAt)
-			},
-		)
+		slices.Reverse(response)
 	}
 
-	respondWithJSON(w, 200, response)
+	// Deleting a chirp doesn't move the newest updated_at, so lists carry
+	// only an ETag; a Last-Modified would let stale lists revalidate.
+	cacheControl := cacheControlPublic
+	if hasViewer {
+		cacheControl = cacheControlPrivate
+	}
+	w.Header().Add("Vary", "Authorization")
+	respondWithCacheableJSON(w, r, response, chirpsETag(response), time.Time{}, cacheControl)
 }
 
 func (cfg *ApiConfig) HandleGetChirp(w http.ResponseWriter, r *http.Request) {
 
 	chirpIdStr := r.PathValue("id")
-	chirpId, err := uuid.Parse(chirpIdStr)
+	chirpId, err := parseUUIDParam("id", chirpIdStr)
 
 	if err != nil {
-		respondWithError(w, 400, "invalid chirp id")
+		respondWithAPIError(w, r, err, "")
 		return
 	}
 
 	chirp, err := cfg.DB.GetChirp(r.Context(), chirpId)
 	if err != nil {
-		respondWithError(w, 404, "chirp not found")
+		respondWithAPIError(w, r, err, "chirp")
 		return
 	}
 
-	respondWithJSON(w, 200, chirp)
+	// Authors' chirps are hidden from users they blocked, as in the lists,
+	// so a viewer's response must not be shared.
+	cacheControl := cacheControlPublic
+	if viewerId, ok := cfg.viewerID(r); ok {
+		blocked, err := cfg.DB.IsBlocked(r.Context(), database.IsBlockedParams{
+			BlockerID: chirp.UserID,
+			BlockedID: viewerId,
+		})
+		if err != nil {
+			respondWithAPIError(w, r, err, "chirp")
+			return
+		}
+		if blocked {
+			respondWithError(w, r, 404, "chirp not found")
+			return
+		}
+		cacheControl = cacheControlPrivate
+	}
+
+	w.Header().Add("Vary", "Authorization")
+	respondWithCacheableJSON(w, r, ChirpsResponse{
+		ID:        chirp.ID,
+		CreatedAt: chirp.CreatedAt,
+		UpdatedAt: chirp.UpdatedAt,
+		Body:      chirp.Body,
+		UserID:    chirp.UserID,
+	}, chirpETag(chirp), chirp.UpdatedAt, cacheControl)
 }
 
 func (cfg *ApiConfig) HandleDeleteChirp(w http.ResponseWriter, r *http.Request) {
 	userIDVal := r.Context().Value(userIDContextKey)
 	userId, ok := userIDVal.(uuid.UUID)
 	if !ok {
-		respondWithError(w, 401, "unauthorized")
+		respondWithError(w, r, 401, "unauthorized")
 		return
 	}
 
 	chirpIdStr := r.PathValue("id")
-	chirpId, err := uuid.Parse(chirpIdStr)
+	chirpId, err := parseUUIDParam("id", chirpIdStr)
 	if err != nil {
-		respondWithError(w, 400, "invalid chirp id")
+		respondWithAPIError(w, r, err, "")
 		return
 	}
 
+	// With If-Match the delete only goes ahead if the chirp is still the
+	// version the client saw. The check is repeated in the delete itself so
+	// a change between the two still fails the precondition.
+	var updatedAt sql.NullTime
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" {
+		chirp, err := cfg.DB.GetChirp(r.Context(), chirpId)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			respondWithAPIError(w, r, err, "chirp")
+			return
+		}
+		if err == nil && chirp.UserID != userId {
+			respondWithError(w, r, 404, "chirp not found or unauthorized")
+			return
+		}
+		if err != nil || !etagMatches(ifMatch, chirpETag(chirp), false) {
+			respondWithAPIError(w, r, preconditionFailed(), "")
+			return
+		}
+		updatedAt = sql.NullTime{Time: chirp.UpdatedAt, Valid: true}
+	}
+
 	rows, err := cfg.DB.DeleteChirp(r.Context(), database.DeleteChirpParams{
-		ID:     chirpId,
-		UserID: userId,
+		ID:        chirpId,
+		UserID:    userId,
+		UpdatedAt: updatedAt,
 	})
 
 	if err != nil {
-		respondWithError(w, 500, "failed to delete chirp")
+		respondWithAPIError(w, r, err, "chirp")
+		return
+	}
+	if rows == 0 && updatedAt.Valid {
+		respondWithAPIError(w, r, preconditionFailed(), "")
 		return
 	}
 	if rows == 0 {
-		respondWithError(w, 404, "chirp not found or unauthorized")
+		respondWithError(w, r, 404, "chirp not found or unauthorized")
 		return
 	}
 
+	cfg.publishChirpEvent(r, pubsub.TypeChirpDeleted, time.Now().UTC(), chirpId, userId, ChirpDeletedEvent{
+		ID:     chirpId,
+		UserID: userId,
+	})
 	respondWithJSON(w, 200, map[string]string{"message": "chirp deleted successfully"})
 }
+
+func (cfg *ApiConfig) HandleRestoreChirp(w http.ResponseWriter, r *http.Request) {
+	userIDVal := r.Context().Value(userIDContextKey)
+	userId, ok := userIDVal.(uuid.UUID)
+	if !ok {
+		respondWithError(w, r, 401, "unauthorized")
+		return
+	}
+
+	chirpIdStr := r.PathValue("id")
+	chirpId, err := parseUUIDParam("id", chirpIdStr)
+	if err != nil {
+		respondWithAPIError(w, r, err, "")
+		return
+	}
+
+	// Only chirps deleted within the restore window can be brought back.
+	cutoff := time.Now().UTC().Add(-cfg.ChirpRestoreWindow)
+	chirp, err := cfg.DB.RestoreChirp(r.Context(), database.RestoreChirpParams{
+		ID:        chirpId,
+		UserID:    userId,
+		DeletedAt: sql.NullTime{Time: cutoff, Valid: true},
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, r, 404, "chirp not found or no longer restorable")
+			return
+		}
+		respondWithAPIError(w, r, err, "chirp")
+		return
+	}
+
+	respondWithJSON(w, 200, ChirpsResponse{
+		ID:        chirp.ID,
+		CreatedAt: chirp.CreatedAt,
+		UpdatedAt: chirp.UpdatedAt,
+		Body:      chirp.Body,
+		UserID:    chirp.UserID,
+	})
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCreateChirp(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ts *testServer) {
+		alice := ts.createUser("alice@example.com")
+
+		chirp := ts.createChirp(alice, "this is a kerfuffle")
+		if chirp.Body != "this is a ****" || chirp.UserID != alice.ID {
+			t.Fatalf("unexpected chirp %+v", chirp)
+		}
+
+		resp := ts.do("POST", "/api/chirps", alice.bearer(), map[string]string{
+			"body": strings.Repeat("a", 141),
+		})
+		expectStatus(t, resp, http.StatusBadRequest)
+
+		resp = ts.do("POST", "/api/chirps", "", map[string]string{"body": "hello"})
+		expectStatus(t, resp, http.StatusUnauthorized)
+
+		if got := ts.cfg.Metrics.CounterValue("chirpy_chirps_created_total"); got != 1 {
+			t.Fatalf("expected 1 chirp created, got %v", got)
+		}
+	})
+}
+
+func TestGetChirps(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ts *testServer) {
+		alice := ts.createUser("alice@example.com")
+		bob := ts.createUser("bob@example.com")
+
+		first := ts.createChirp(alice, "first")
+		ts.createChirp(bob, "second")
+		last := ts.createChirp(alice, "third")
+
+		chirps := ts.listChirps("/api/chirps", "")
+		if len(chirps) != 3 || chirps[0].ID != first.ID {
+			t.Fatalf("expected 3 chirps oldest first, got %+v", chirps)
+		}
+
+		chirps = ts.listChirps("/api/chirps?sort=desc", "")
+		if chirps[0].ID != last.ID {
+			t.Fatalf("expected newest chirp first, got %+v", chirps[0])
+		}
+
+		chirps = ts.listChirps("/api/chirps?author_id="+alice.ID.String(), "")
+		if len(chirps) != 2 {
+			t.Fatalf("expected 2 chirps by alice, got %d", len(chirps))
+		}
+
+		resp := ts.do("GET", "/api/chirps?author_id=nope", "", nil)
+		expectStatus(t, resp, http.StatusBadRequest)
+	})
+}
+
+func TestGetChirp(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ts *testServer) {
+		alice := ts.createUser("alice@example.com")
+		chirp := ts.createChirp(alice, "hello")
+
+		resp := ts.do("GET", "/api/chirps/"+chirp.ID.String(), "", nil)
+		expectStatus(t, resp, http.StatusOK)
+
+		var got ChirpsResponse
+		decodeBody(t, resp, &got)
+		if got.ID != chirp.ID || got.Body != "hello" {
+			t.Fatalf("unexpected chirp %+v", got)
+		}
+
+		resp = ts.do("GET", "/api/chirps/not-a-uuid", "", nil)
+		expectStatus(t, resp, http.StatusBadRequest)
+
+		resp = ts.do("GET", "/api/chirps/00000000-0000-0000-0000-000000000001", "", nil)
+		expectStatus(t, resp, http.StatusNotFound)
+	})
+}
+
+func TestDeleteAndRestoreChirp(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ts *testServer) {
+		alice := ts.createUser("alice@example.com")
+		bob := ts.createUser("bob@example.com")
+		chirp := ts.createChirp(alice, "hello")
+		path := "/api/chirps/" + chirp.ID.String()
+
+		resp := ts.do("DELETE", path, bob.bearer(), nil)
+		expectStatus(t, resp, http.StatusNotFound)
+
+		resp = ts.do("DELETE", path, alice.bearer(), nil)
+		expectStatus(t, resp, http.StatusOK)
+
+		resp = ts.do("GET", path, "", nil)
+		expectStatus(t, resp, http.StatusNotFound)
+
+		if chirps := ts.listChirps("/api/chirps", ""); len(chirps) != 0 {
+			t.Fatalf("expected deleted chirp to be hidden, got %d chirps", len(chirps))
+		}
+
+		resp = ts.do("POST", path+"/restore", bob.bearer(), nil)
+		expectStatus(t, resp, http.StatusNotFound)
+
+		resp = ts.do("POST", path+"/restore", alice.bearer(), nil)
+		expectStatus(t, resp, http.StatusOK)
+
+		resp = ts.do("GET", path, "", nil)
+		expectStatus(t, resp, http.StatusOK)
+	})
+}
+
+func TestRestoreChirp_WindowExpired(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ts *testServer) {
+		ts.cfg.ChirpRestoreWindow = 0
+		alice := ts.createUser("alice@example.com")
+		chirp := ts.createChirp(alice, "hello")
+		path := "/api/chirps/" + chirp.ID.String()
+
+		expectStatus(t, ts.do("DELETE", path, alice.bearer(), nil), http.StatusOK)
+
+		resp := ts.do("POST", path+"/restore", alice.bearer(), nil)
+		expectStatus(t, resp, http.StatusNotFound)
+	})
+}
+
+func TestPurgeDeletedChirps(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ts *testServer) {
+		ts.cfg.ChirpRetention = 0
+		alice := ts.createUser("alice@example.com")
+		chirp := ts.createChirp(alice, "hello")
+
+		expectStatus(t, ts.do("DELETE", "/api/chirps/"+chirp.ID.String(), alice.bearer(), nil), http.StatusOK)
+
+		ts.cfg.purgeDeletedChirps(context.Background())
+
+		chirps, err := ts.store.ExportChirpsByAuthor(context.Background(), alice.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(chirps) != 0 {
+			t.Fatalf("expected deleted chirp to be purged, got %d", len(chirps))
+		}
+	})
+}
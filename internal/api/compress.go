@@ -0,0 +1,323 @@
+package api
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+)
+
+// compressMinSize is the smallest body worth compressing; below it the
+// encoding overhead outweighs the savings.
+const compressMinSize = 1024
+
+// Supported content codings, in order of preference when a client rates
+// them equally.
+const (
+	encodingBrotli = "br"
+	encodingGzip   = "gzip"
+)
+
+var supportedEncodings = []string{encodingBrotli, encodingGzip}
+
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(io.Writer)
+}
+
+var encoderPools = map[string]*sync.Pool{
+	encodingBrotli: {New: func() any { return brotli.NewWriterLevel(nil, brotli.DefaultCompression) }},
+	encodingGzip:   {New: func() any { return gzip.NewWriter(nil) }},
+}
+
+// MiddlewareCompress compresses responses with brotli or gzip according to
+// the request's Accept-Encoding. Small bodies, types that are already
+// compressed (images, archives) and responses the handler encoded itself
+// are passed through untouched. Compressed responses get their strong ETag
+// suffixed with the coding, and the suffix is stripped from incoming
+// validators so handlers only ever see their own tags.
+func MiddlewareCompress(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inm := r.Header.Get("If-None-Match")
+		stripEncodedETags(r.Header, "If-None-Match")
+		stripEncodedETags(r.Header, "If-Match")
+
+		cw := &compressWriter{
+			ResponseWriter: w,
+			encoding:       negotiateEncoding(r.Header.Get("Accept-Encoding"), supportedEncodings),
+			ifNoneMatch:    inm,
+			skip:           r.Method == http.MethodHead || r.Header.Get("Range") != "",
+		}
+		defer cw.Close()
+
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// negotiateEncoding picks the offered coding the client rates highest, or
+// "" if it accepts none of them. Ties go to the earlier offer.
+func negotiateEncoding(accept string, offered []string) string {
+	if accept == "" {
+		return ""
+	}
+
+	qualities := map[string]float64{}
+	for _, part := range strings.Split(accept, ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		q := 1.0
+		if name, value, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(name) == "q" {
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		qualities[coding] = q
+	}
+
+	best, bestQ := "", 0.0
+	for _, coding := range offered {
+		q, ok := qualities[coding]
+		if !ok {
+			q, ok = qualities["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = coding, q
+		}
+	}
+	return best
+}
+
+// compressibleType reports whether a body of the given Content-Type is
+// likely to shrink when compressed.
+func compressibleType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	switch {
+	case mediaType == "text/event-stream":
+		// Events are flushed one at a time as they happen, leaving
+		// nothing for the compressor to work with.
+		return false
+	case strings.HasPrefix(mediaType, "text/"):
+		return true
+	case strings.HasSuffix(mediaType, "+json"), strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/javascript", "application/xml", "image/svg+xml", "application/wasm":
+		return true
+	}
+	return false
+}
+
+// encodedETag marks a strong ETag as belonging to the encoded
+// representation. Weak tags are left alone.
+func encodedETag(etag, encoding string) string {
+	if !strings.HasSuffix(etag, `"`) || strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return strings.TrimSuffix(etag, `"`) + "-" + encoding + `"`
+}
+
+// stripEncodedETags rewrites a validator header so ETags that
+// MiddlewareCompress suffixed compare equal to the handler's own.
+func stripEncodedETags(h http.Header, name string) {
+	value := h.Get(name)
+	if value == "" {
+		return
+	}
+
+	tags := strings.Split(value, ",")
+	for i, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		for _, encoding := range supportedEncodings {
+			suffix := "-" + encoding + `"`
+			if strings.HasSuffix(tag, suffix) {
+				tag = strings.TrimSuffix(tag, suffix) + `"`
+				break
+			}
+		}
+		tags[i] = tag
+	}
+	h.Set(name, strings.Join(tags, ", "))
+}
+
+// compressWriter buffers the start of a response until it knows whether
+// the body is large enough to compress, then either streams it through an
+// encoder or writes it unchanged.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding    string
+	ifNoneMatch string
+	skip        bool
+
+	status  int
+	buf     []byte
+	decided bool
+	enc     encoder
+}
+
+func (cw *compressWriter) WriteHeader(code int) {
+	if code >= 100 && code < 200 {
+		cw.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if cw.status != 0 {
+		return
+	}
+	cw.status = code
+
+	// Bodyless responses and ones whose size is already known to be small
+	// need no buffering.
+	if code == http.StatusNoContent || code == http.StatusNotModified {
+		cw.decide(false)
+		return
+	}
+	if n, err := strconv.Atoi(cw.Header().Get("Content-Length")); err == nil {
+		cw.decide(n >= compressMinSize)
+	}
+}
+
+func (cw *compressWriter) Write(b []byte) (int, error) {
+	if cw.status == 0 {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if !cw.decided {
+		cw.buf = append(cw.buf, b...)
+		if len(cw.buf) < compressMinSize {
+			return len(b), nil
+		}
+		cw.decide(true)
+		if err := cw.flushBuffer(); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+
+	if cw.enc != nil {
+		return cw.enc.Write(b)
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+// decide writes the response header, compressing the body from here on if
+// large is set and the response is eligible.
+func (cw *compressWriter) decide(large bool) {
+	cw.decided = true
+	h := cw.Header()
+
+	eligible := !cw.skip &&
+		h.Get("Content-Encoding") == "" &&
+		cw.status != http.StatusPartialContent &&
+		compressibleType(h.Get("Content-Type"))
+
+	if cw.status == http.StatusNotModified && cw.encoding != "" {
+		// Hand back the tag the client revalidated with if it was ours.
+		if etag := h.Get("ETag"); etag != "" && etagMatches(cw.ifNoneMatch, encodedETag(etag, cw.encoding), true) {
+			h.Set("ETag", encodedETag(etag, cw.encoding))
+			h.Add("Vary", "Accept-Encoding")
+		}
+	}
+
+	// Handlers serving precompressed files set the coding themselves but
+	// tag the file's content.
+	if encoding := h.Get("Content-Encoding"); slices.Contains(supportedEncodings, encoding) {
+		if etag := h.Get("ETag"); etag != "" {
+			h.Set("ETag", encodedETag(etag, encoding))
+		}
+	}
+
+	if eligible {
+		h.Add("Vary", "Accept-Encoding")
+	}
+	if eligible && large && cw.encoding != "" {
+		h.Set("Content-Encoding", cw.encoding)
+		h.Del("Content-Length")
+		if etag := h.Get("ETag"); etag != "" {
+			h.Set("ETag", encodedETag(etag, cw.encoding))
+		}
+
+		cw.enc = encoderPools[cw.encoding].Get().(encoder)
+		cw.enc.Reset(cw.ResponseWriter)
+	}
+
+	status := cw.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	cw.ResponseWriter.WriteHeader(status)
+}
+
+func (cw *compressWriter) flushBuffer() error {
+	if len(cw.buf) == 0 {
+		return nil
+	}
+	buf := cw.buf
+	cw.buf = nil
+
+	var err error
+	if cw.enc != nil {
+		_, err = cw.enc.Write(buf)
+	} else {
+		_, err = cw.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// Flush sends whatever has been buffered so far, so streaming handlers are
+// not held up waiting for compressMinSize bytes.
+func (cw *compressWriter) Flush() {
+	if cw.status == 0 {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if !cw.decided {
+		cw.decide(len(cw.buf) >= compressMinSize)
+	}
+	if err := cw.flushBuffer(); err != nil {
+		return
+	}
+	if cw.enc != nil {
+		if err := cw.enc.Flush(); err != nil {
+			return
+		}
+	}
+	http.NewResponseController(cw.ResponseWriter).Flush()
+}
+
+// Close finishes the response once the handler has returned.
+func (cw *compressWriter) Close() error {
+	if cw.status == 0 {
+		// The handler wrote nothing; let net/http send its default 200.
+		return nil
+	}
+	if !cw.decided {
+		cw.decide(false)
+	}
+	err := cw.flushBuffer()
+
+	if cw.enc != nil {
+		if closeErr := cw.enc.Close(); err == nil {
+			err = closeErr
+		}
+		cw.enc.Reset(nil)
+		encoderPools[cw.encoding].Put(cw.enc)
+		cw.enc = nil
+	}
+	return err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", ""},
+		{"identity", ""},
+		{"gzip", "gzip"},
+		{"gzip, deflate, br", "br"},
+		{"br;q=0.5, gzip", "gzip"},
+		{"br;q=0, gzip;q=0", ""},
+		{"*", "br"},
+		{"*;q=0.1, gzip;q=0.5", "gzip"},
+		{"GZIP", "gzip"},
+		{"br;q=nope, gzip", "gzip"},
+	}
+
+	for _, tt := range tests {
+		if got := negotiateEncoding(tt.accept, supportedEncodings); got != tt.want {
+			t.Errorf("negotiateEncoding(%q) = %q, want %q", tt.accept, got, tt.want)
+		}
+	}
+}
+
+// serveCompressed runs handler behind MiddlewareCompress with the given
+// request headers.
+func serveCompressed(handler http.HandlerFunc, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", "/", nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	MiddlewareCompress(handler).ServeHTTP(rec, req)
+	return rec
+}
+
+func decompress(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+
+	var r io.Reader
+	switch rec.Header().Get("Content-Encoding") {
+	case "gzip":
+		zr, err := gzip.NewReader(rec.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = zr
+	case "br":
+		r = brotli.NewReader(rec.Body)
+	default:
+		r = rec.Body
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestMiddlewareCompress(t *testing.T) {
+	large := `{"body":"` + strings.Repeat("chirp ", 400) + `"}`
+	jsonHandler := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			// Written in pieces to exercise buffering across writes.
+			for len(body) > 100 {
+				io.WriteString(w, body[:100])
+				body = body[100:]
+			}
+			io.WriteString(w, body)
+		}
+	}
+
+	for _, encoding := range []string{"gzip", "br"} {
+		rec := serveCompressed(jsonHandler(large), map[string]string{"Accept-Encoding": encoding})
+		if got := rec.Header().Get("Content-Encoding"); got != encoding {
+			t.Fatalf("expected %s encoding, got %q", encoding, got)
+		}
+		if rec.Header().Get("Vary") != "Accept-Encoding" {
+			t.Fatalf("expected Vary: Accept-Encoding, got %q", rec.Header().Get("Vary"))
+		}
+		if rec.Body.Len() >= len(large) {
+			t.Fatalf("expected %s body to shrink, got %d bytes", encoding, rec.Body.Len())
+		}
+		if got := decompress(t, rec); got != large {
+			t.Fatalf("%s round trip mismatch", encoding)
+		}
+	}
+
+	rec := serveCompressed(jsonHandler(large), nil)
+	if rec.Header().Get("Content-Encoding") != "" || rec.Body.String() != large {
+		t.Fatal("expected identity response without Accept-Encoding")
+	}
+	if rec.Header().Get("Vary") != "Accept-Encoding" {
+		t.Fatal("identity responses must still vary on Accept-Encoding")
+	}
+
+	rec = serveCompressed(jsonHandler(`{"ok":true}`), map[string]string{"Accept-Encoding": "gzip"})
+	if rec.Header().Get("Content-Encoding") != "" || rec.Body.String() != `{"ok":true}` {
+		t.Fatal("expected small body to be sent uncompressed")
+	}
+
+	png := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte(large))
+	}
+	rec = serveCompressed(png, map[string]string{"Accept-Encoding": "gzip"})
+	if rec.Header().Get("Content-Encoding") != "" || rec.Header().Get("Vary") != "" {
+		t.Fatalf("expected image to pass through untouched, got %v", rec.Header())
+	}
+
+	preEncoded := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write([]byte(large))
+	}
+	rec = serveCompressed(preEncoded, map[string]string{"Accept-Encoding": "br"})
+	if rec.Header().Get("Content-Encoding") != "gzip" || rec.Body.String() != large {
+		t.Fatal("expected handler's own encoding to be left alone")
+	}
+}
+
+func TestMiddlewareCompress_ETags(t *testing.T) {
+	payload := map[string]string{"body": strings.Repeat("chirp ", 400)}
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		respondWithCacheableJSON(w, r, payload, `"v1"`, time.Time{}, cacheControlPublic)
+	}
+
+	rec := serveCompressed(handler, map[string]string{"Accept-Encoding": "gzip"})
+	etag := rec.Header().Get("ETag")
+	if etag != `"v1-gzip"` {
+		t.Fatalf("expected coding-specific ETag, got %q", etag)
+	}
+
+	rec = serveCompressed(handler, map[string]string{"Accept-Encoding": "gzip", "If-None-Match": etag})
+	if rec.Code != http.StatusNotModified {
+		t.Fatalf("expected 304 revalidating %s, got %d", etag, rec.Code)
+	}
+	if got := rec.Header().Get("ETag"); got != etag {
+		t.Fatalf("expected 304 to repeat %s, got %q", etag, got)
+	}
+
+	rec = serveCompressed(handler, map[string]string{"If-None-Match": `"v1"`})
+	if rec.Code != http.StatusNotModified || rec.Header().Get("ETag") != `"v1"` {
+		t.Fatalf("expected identity revalidation to keep the plain tag, got %d %q", rec.Code, rec.Header().Get("ETag"))
+	}
+
+	var ifMatch string
+	seen := func(w http.ResponseWriter, r *http.Request) {
+		ifMatch = r.Header.Get("If-Match")
+	}
+	serveCompressed(seen, map[string]string{"If-Match": `"v1-br", W/"v2-gzip"`})
+	if ifMatch != `"v1", W/"v2"` {
+		t.Fatalf("expected coding suffixes stripped from If-Match, got %q", ifMatch)
+	}
+}
@@ -2,14 +2,68 @@ package api
 
 import (
 	"sync/atomic"
+	"time"
 
+	"github.com/ihyaulhaq/go-server/internal/audit"
 	"github.com/ihyaulhaq/go-server/internal/database"
+	"github.com/ihyaulhaq/go-server/internal/health"
+	"github.com/ihyaulhaq/go-server/internal/metrics"
+	"github.com/ihyaulhaq/go-server/internal/pubsub"
+	"github.com/ihyaulhaq/go-server/internal/ratelimit"
 )
 
 type ApiConfig struct {
-	FileserverHits atomic.Int32
-	DB             *database.Queries
-	Platform       string
-	SecretKey      string
-	PolkaKey       string
+	Metrics   *metrics.Metrics
+	DB        database.Store
+	Platform  string
+	SecretKey string
+	PolkaKey  string
+	AdminKey  string
+	Auditor   audit.Auditor
+
+	// SeedFile is the fixture loaded by POST /admin/seed. When empty, the
+	// fixture embedded in the binary is used.
+	SeedFile string
+
+	// ChirpRestoreWindow is how long a deleted chirp can still be restored.
+	ChirpRestoreWindow time.Duration
+	// ChirpRetention is how long a deleted chirp is kept before it is purged.
+	ChirpRetention time.Duration
+	// AccountDeletionDelay is the cooling-off period before a self-deleted
+	// account is removed.
+	AccountDeletionDelay time.Duration
+
+	// HealthCheckers are run by the readiness endpoint, each bounded by
+	// HealthCheckTimeout.
+	HealthCheckers     []health.Checker
+	HealthCheckTimeout time.Duration
+
+	// IdempotencyTTL is how long a response stored under an Idempotency-Key
+	// is replayed.
+	IdempotencyTTL time.Duration
+
+	// RateLimiter holds the token buckets for RateLimits, which maps route
+	// patterns to their policy. Rate limiting is off when RateLimiter is nil.
+	RateLimiter ratelimit.Store
+	RateLimits  map[string]ratelimit.Policy
+
+	// CORS governs cross-origin access to every route.
+	CORS CORSPolicy
+	// Security is the default set of security headers; RouteSecurity
+	// replaces it for the route patterns it lists.
+	Security      SecurityPolicy
+	RouteSecurity map[string]SecurityPolicy
+
+	// Events receives chirp changes and EventHub fans them out to
+	// GET /api/chirps/stream. With several replicas, Events must reach
+	// every replica's hub; with one, it can be EventHub itself. Events are
+	// not published when Events is nil.
+	Events   pubsub.Publisher
+	EventHub *pubsub.Hub
+
+	// RequireClientCert makes /admin/ routes demand a verified TLS client
+	// certificate.
+	RequireClientCert bool
+
+	draining atomic.Bool
 }
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// corsExposedHeaders are the response headers this API sets that browsers
+// hide from cross-origin scripts unless they are listed.
+var corsExposedHeaders = []string{
+	"ETag",
+	"Retry-After",
+	"RateLimit-Limit",
+	"RateLimit-Remaining",
+	"RateLimit-Reset",
+	"RateLimit-Policy",
+	idempotentReplayedHeader,
+	requestIDHeader,
+}
+
+// CORSPolicy says which cross-origin callers may use the API. CORS is off
+// when AllowedOrigins is empty; "*" allows any origin but cannot be
+// combined with AllowCredentials.
+type CORSPolicy struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight response.
+	MaxAge time.Duration
+}
+
+func (p CORSPolicy) allowOrigin(origin string) (string, bool) {
+	if origin == "" {
+		return "", false
+	}
+	for _, allowed := range p.AllowedOrigins {
+		if allowed == "*" && !p.AllowCredentials {
+			return "*", true
+		}
+		if strings.EqualFold(allowed, origin) {
+			return origin, true
+		}
+	}
+	return "", false
+}
+
+// allowsHeaders reports whether every header in a preflight's
+// Access-Control-Request-Headers is allowed.
+func (p CORSPolicy) allowsHeaders(requested string) bool {
+	for _, name := range strings.Split(requested, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !slices.ContainsFunc(p.AllowedHeaders, func(allowed string) bool {
+			return strings.EqualFold(allowed, name)
+		}) {
+			return false
+		}
+	}
+	return true
+}
+
+// setOriginHeaders sets the headers shared by preflight and actual
+// responses, reporting whether the origin is allowed.
+func (p CORSPolicy) setOriginHeaders(h http.Header, origin string) bool {
+	allowed, ok := p.allowOrigin(origin)
+	if allowed != "*" {
+		h.Add("Vary", "Origin")
+	}
+	if !ok {
+		return false
+	}
+
+	h.Set("Access-Control-Allow-Origin", allowed)
+	if p.AllowCredentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+	return true
+}
+
+// MiddlewareCORS adds CORS headers to responses for allowed origins.
+// Preflight requests are answered by HandleCORSPreflight.
+func (cfg *ApiConfig) MiddlewareCORS(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(cfg.CORS.AllowedOrigins) > 0 && cfg.CORS.setOriginHeaders(w.Header(), r.Header.Get("Origin")) {
+			w.Header().Set("Access-Control-Expose-Headers", strings.Join(corsExposedHeaders, ", "))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// HandleCORSPreflight answers OPTIONS requests. A preflight asking for an
+// origin, method or header outside the policy gets no CORS headers, which
+// the browser treats as a refusal.
+func (cfg *ApiConfig) HandleCORSPreflight(w http.ResponseWriter, r *http.Request) {
+	h := w.Header()
+	method := r.Header.Get("Access-Control-Request-Method")
+	if len(cfg.CORS.AllowedOrigins) == 0 || method == "" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	h.Add("Vary", "Access-Control-Request-Method")
+	h.Add("Vary", "Access-Control-Request-Headers")
+	if !cfg.CORS.setOriginHeaders(h, r.Header.Get("Origin")) ||
+		!slices.Contains(cfg.CORS.AllowedMethods, method) ||
+		!cfg.CORS.allowsHeaders(r.Header.Get("Access-Control-Request-Headers")) {
+		h.Del("Access-Control-Allow-Origin")
+		h.Del("Access-Control-Allow-Credentials")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	h.Set("Access-Control-Allow-Methods", strings.Join(cfg.CORS.AllowedMethods, ", "))
+	if len(cfg.CORS.AllowedHeaders) > 0 {
+		h.Set("Access-Control-Allow-Headers", strings.Join(cfg.CORS.AllowedHeaders, ", "))
+	}
+	if cfg.CORS.MaxAge > 0 {
+		h.Set("Access-Control-Max-Age", strconv.Itoa(int(cfg.CORS.MaxAge.Seconds())))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ihyaulhaq/go-server/internal/database/memstore"
+)
+
+func newCORSTestServer(t *testing.T, policy CORSPolicy) *testServer {
+	ts := newTestServer(t, memstore.New())
+	ts.cfg.CORS = policy
+	return ts
+}
+
+var testCORSPolicy = CORSPolicy{
+	AllowedOrigins: []string{"https://chirpy.example"},
+	AllowedMethods: []string{"GET", "POST", "DELETE"},
+	AllowedHeaders: []string{"Authorization", "Content-Type"},
+	MaxAge:         10 * time.Minute,
+}
+
+func TestCORS_Preflight(t *testing.T) {
+	ts := newCORSTestServer(t, testCORSPolicy)
+
+	resp := ts.doWithHeaders("OPTIONS", "/api/chirps", "", map[string]string{
+		"Origin":                         "https://chirpy.example",
+		"Access-Control-Request-Method":  "POST",
+		"Access-Control-Request-Headers": "content-type, authorization",
+	})
+	expectStatus(t, resp, http.StatusNoContent)
+	h := resp.Header
+	if h.Get("Access-Control-Allow-Origin") != "https://chirpy.example" {
+		t.Fatalf("expected origin to be allowed, got %v", h)
+	}
+	if h.Get("Access-Control-Allow-Methods") != "GET, POST, DELETE" ||
+		h.Get("Access-Control-Allow-Headers") != "Authorization, Content-Type" ||
+		h.Get("Access-Control-Max-Age") != "600" {
+		t.Fatalf("unexpected preflight headers %v", h)
+	}
+	if h.Get("Access-Control-Allow-Credentials") != "" {
+		t.Fatal("credentials must not be allowed unless configured")
+	}
+	if !strings.Contains(strings.Join(h.Values("Vary"), ","), "Origin") {
+		t.Fatalf("expected Vary: Origin, got %v", h.Values("Vary"))
+	}
+
+	refused := []map[string]string{
+		{"Origin": "https://evil.example", "Access-Control-Request-Method": "POST"},
+		{"Origin": "https://chirpy.example", "Access-Control-Request-Method": "PUT"},
+		{"Origin": "https://chirpy.example", "Access-Control-Request-Method": "POST", "Access-Control-Request-Headers": "X-Custom"},
+	}
+	for _, headers := range refused {
+		resp = ts.doWithHeaders("OPTIONS", "/api/chirps", "", headers)
+		expectStatus(t, resp, http.StatusNoContent)
+		if got := resp.Header.Get("Access-Control-Allow-Origin"); got != "" {
+			t.Fatalf("expected preflight %v to be refused, got origin %q", headers, got)
+		}
+	}
+}
+
+func TestCORS_ActualRequest(t *testing.T) {
+	ts := newCORSTestServer(t, testCORSPolicy)
+
+	resp := ts.doWithHeaders("GET", "/api/chirps", "", map[string]string{"Origin": "https://chirpy.example"})
+	expectStatus(t, resp, http.StatusOK)
+	if resp.Header.Get("Access-Control-Allow-Origin") != "https://chirpy.example" {
+		t.Fatalf("expected allowed origin, got %v", resp.Header)
+	}
+	if !strings.Contains(resp.Header.Get("Access-Control-Expose-Headers"), "ETag") {
+		t.Fatalf("expected ETag to be exposed, got %q", resp.Header.Get("Access-Control-Expose-Headers"))
+	}
+
+	resp = ts.doWithHeaders("GET", "/api/chirps", "", map[string]string{"Origin": "https://evil.example"})
+	expectStatus(t, resp, http.StatusOK)
+	if resp.Header.Get("Access-Control-Allow-Origin") != "" {
+		t.Fatal("disallowed origin must not get CORS headers")
+	}
+
+	// Errors are readable cross-origin too.
+	resp = ts.doWithHeaders("DELETE", "/api/chirps/"+strings.Repeat("0", 8), "", map[string]string{"Origin": "https://chirpy.example"})
+	if resp.StatusCode < 400 || resp.Header.Get("Access-Control-Allow-Origin") == "" {
+		t.Fatalf("expected CORS headers on error %d, got %v", resp.StatusCode, resp.Header)
+	}
+}
+
+func TestCORS_WildcardAndCredentials(t *testing.T) {
+	ts := newCORSTestServer(t, CORSPolicy{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"GET"}})
+	resp := ts.doWithHeaders("GET", "/api/chirps", "", map[string]string{"Origin": "https://anywhere.example"})
+	if resp.Header.Get("Access-Control-Allow-Origin") != "*" || resp.Header.Get("Vary") == "Origin" {
+		t.Fatalf("expected wildcard origin without Vary: Origin, got %v", resp.Header)
+	}
+
+	policy := testCORSPolicy
+	policy.AllowCredentials = true
+	ts = newCORSTestServer(t, policy)
+	resp = ts.doWithHeaders("GET", "/api/chirps", "", map[string]string{"Origin": "https://chirpy.example"})
+	if resp.Header.Get("Access-Control-Allow-Credentials") != "true" || resp.Header.Get("Access-Control-Allow-Origin") != "https://chirpy.example" {
+		t.Fatalf("expected credentialed CORS echoing the origin, got %v", resp.Header)
+	}
+}
+
+func TestCORS_Disabled(t *testing.T) {
+	ts := newTestServer(t, memstore.New())
+
+	resp := ts.doWithHeaders("OPTIONS", "/api/chirps", "", map[string]string{
+		"Origin":                        "https://chirpy.example",
+		"Access-Control-Request-Method": "GET",
+	})
+	expectStatus(t, resp, http.StatusNoContent)
+	if resp.Header.Get("Access-Control-Allow-Origin") != "" {
+		t.Fatal("CORS must be off without allowed origins")
+	}
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	ts := newTestServer(t, memstore.New())
+	ts.cfg.Security = SecurityPolicy{
+		ContentSecurityPolicy: "default-src 'none'; frame-ancestors 'none'",
+		ReferrerPolicy:        "no-referrer",
+		HSTSMaxAge:            time.Hour,
+	}
+	ts.cfg.RouteSecurity = map[string]SecurityPolicy{
+		"GET /app/": {ContentSecurityPolicy: "default-src 'self'"},
+	}
+
+	resp := ts.do("GET", "/api/chirps", "", nil)
+	h := resp.Header
+	if h.Get("Content-Security-Policy") != "default-src 'none'; frame-ancestors 'none'" ||
+		h.Get("Referrer-Policy") != "no-referrer" ||
+		h.Get("X-Content-Type-Options") != "nosniff" {
+		t.Fatalf("unexpected API security headers %v", h)
+	}
+	if h.Get("Strict-Transport-Security") != "" {
+		t.Fatal("HSTS must only be sent over TLS")
+	}
+
+	resp = ts.do("GET", "/app/", "", nil)
+	h = resp.Header
+	if h.Get("Content-Security-Policy") != "default-src 'self'" || h.Get("Referrer-Policy") != "" {
+		t.Fatalf("expected /app/ override, got %v", h)
+	}
+	if h.Get("X-Content-Type-Options") != "nosniff" {
+		t.Fatal("nosniff must be sent on every route")
+	}
+
+	// httptest marks https requests as arriving over TLS.
+	rec := httptest.NewRecorder()
+	ts.cfg.MiddlewareSecurityHeaders(http.NotFoundHandler()).ServeHTTP(rec, httptest.NewRequest("GET", "https://chirpy.example/api/chirps", nil))
+	if got := rec.Header().Get("Strict-Transport-Security"); got != "max-age=3600; includeSubDomains" {
+		t.Fatalf("unexpected HSTS header %q", got)
+	}
+}
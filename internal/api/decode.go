@@ -0,0 +1,91 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// maxJSONBodyBytes caps request bodies; every JSON payload this API accepts
+// is a handful of short fields.
+const maxJSONBodyBytes = 1 << 20
+
+// decodeJSON strictly decodes the request body into a T and validates it
+// against its `validate` struct tags. The body must be a single JSON object
+// sent as application/json, no larger than maxJSONBodyBytes, with no fields
+// that T does not declare. Errors are *APIError values ready for
+// respondWithAPIError.
+func decodeJSON[T any](w http.ResponseWriter, r *http.Request) (T, error) {
+	var dst T
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		return dst, &APIError{
+			Status: http.StatusUnsupportedMediaType,
+			Code:   CodeUnsupportedMediaType,
+			Detail: "Content-Type must be application/json",
+		}
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxJSONBodyBytes)
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(&dst); err != nil {
+		return dst, decodeError(err)
+	}
+	if err := dec.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		return dst, invalidBody("request body must contain a single JSON object", err)
+	}
+
+	if err := validateStruct(dst); err != nil {
+		return dst, err
+	}
+	return dst, nil
+}
+
+// decodeError describes a json decoding failure without echoing the body.
+func decodeError(err error) *APIError {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var maxBytesErr *http.MaxBytesError
+
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return &APIError{
+			Status: http.StatusRequestEntityTooLarge,
+			Code:   CodePayloadTooLarge,
+			Detail: fmt.Sprintf("request body must not exceed %d bytes", maxBytesErr.Limit),
+			Err:    err,
+		}
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		return invalidBody("request body is not valid JSON", err)
+	case errors.As(err, &typeErr):
+		if typeErr.Field != "" {
+			return validationError(FieldError{
+				Field:   typeErr.Field,
+				Code:    "invalid_type",
+				Message: "must not be a JSON " + typeErr.Value,
+			})
+		}
+		return invalidBody("request body must be a JSON object", err)
+	case errors.Is(err, io.EOF):
+		return invalidBody("request body must not be empty", err)
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return validationError(FieldError{
+			Field:   field,
+			Code:    "unknown_field",
+			Message: "is not a recognised field",
+		})
+	}
+	return invalidBody("invalid request payload", err)
+}
+
+func invalidBody(detail string, err error) *APIError {
+	return &APIError{Status: http.StatusBadRequest, Code: CodeInvalidRequest, Detail: detail, Err: err}
+}
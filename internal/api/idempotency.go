@@ -0,0 +1,252 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ihyaulhaq/go-server/internal/database"
+)
+
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+
+	// idempotencyLockTimeout bounds how long an in-flight request holds its
+	// key; it comfortably exceeds the server's write timeout.
+	idempotencyLockTimeout = time.Minute
+	// idempotencyInFlightRetry is the Retry-After sent to a duplicate that
+	// arrives while the original is still running.
+	idempotencyInFlightRetry = time.Second
+	// idempotencyStorageTimeout bounds storing or releasing a key after the
+	// handler has run, when the request context may already be done.
+	idempotencyStorageTimeout = 5 * time.Second
+)
+
+// MiddlewareIdempotency makes a POST safe to retry when the client sends an
+// Idempotency-Key. The first response for a key is stored per caller for
+// cfg.IdempotencyTTL and replayed verbatim to retries with the same method,
+// path and body. Reusing a key for a different request gets a 422, and a
+// retry that arrives while the original is still running gets a 409.
+// Server errors are not stored, so the client can retry them for real.
+//
+// A request that has held its key for longer than idempotencyLockTimeout is
+// presumed lost with its replica, and the key may be claimed again.
+func (cfg *ApiConfig) MiddlewareIdempotency(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !validIdempotencyKey(key) {
+			respondWithError(w, r, http.StatusBadRequest, "Idempotency-Key must be 1 to 255 printable ASCII characters")
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxJSONBodyBytes))
+		if err != nil {
+			respondWithAPIError(w, r, decodeError(err), "")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		scope := cfg.idempotencyScope(r)
+		hash := requestHash(r, body)
+
+		now := time.Now().UTC()
+		_, err = cfg.DB.ClaimIdempotencyKey(r.Context(), database.ClaimIdempotencyKeyParams{
+			Scope:       scope,
+			Key:         key,
+			RequestHash: hash,
+			ExpiresAt:   now.Add(cfg.IdempotencyTTL),
+			StaleBefore: now.Add(-idempotencyLockTimeout),
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			cfg.replayIdempotent(w, r, scope, key, hash)
+			return
+		}
+		if err != nil {
+			respondWithAPIError(w, r, err, "idempotency key")
+			return
+		}
+
+		rec := &idempotencyRecorder{ResponseWriter: w, before: w.Header().Clone()}
+		stored := false
+		defer func() {
+			// Release the key if the response was not stored, including
+			// when next panics, so a retry is not stuck behind it.
+			if !stored {
+				cfg.releaseIdempotencyKey(r, scope, key)
+			}
+		}()
+
+		next.ServeHTTP(rec, r)
+
+		if rec.Status() >= 500 {
+			return
+		}
+
+		headers, err := json.Marshal(rec.headers)
+		if err != nil {
+			requestLogger(r).Error("error encoding idempotent response headers", "error", err)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), idempotencyStorageTimeout)
+		defer cancel()
+
+		err = cfg.DB.CompleteIdempotencyKey(ctx, database.CompleteIdempotencyKeyParams{
+			Scope:   scope,
+			Key:     key,
+			Status:  sql.NullInt32{Int32: int32(rec.Status()), Valid: true},
+			Headers: sql.NullString{String: string(headers), Valid: true},
+			Body:    rec.body.Bytes(),
+		})
+		if err != nil {
+			requestLogger(r).Error("error storing idempotent response", "error", err)
+			return
+		}
+		stored = true
+	})
+}
+
+// replayIdempotent answers a request whose key is already taken.
+func (cfg *ApiConfig) replayIdempotent(w http.ResponseWriter, r *http.Request, scope, key, hash string) {
+	prior, err := cfg.DB.GetIdempotencyKey(r.Context(), database.GetIdempotencyKeyParams{
+		Scope: scope,
+		Key:   key,
+	})
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		respondWithAPIError(w, r, err, "idempotency key")
+		return
+	}
+
+	switch {
+	case err == nil && prior.RequestHash != hash:
+		respondWithAPIError(w, r, &APIError{
+			Status: http.StatusUnprocessableEntity,
+			Code:   CodeIdempotencyKeyReused,
+			Detail: "Idempotency-Key was already used for a different request",
+		}, "")
+	case err != nil || !prior.Status.Valid:
+		// Still running, or released between our claim and this read.
+		w.Header().Set("Retry-After", ceilSeconds(idempotencyInFlightRetry))
+		respondWithAPIError(w, r, &APIError{
+			Status: http.StatusConflict,
+			Code:   CodeIdempotencyKeyInFlight,
+			Detail: "a request with this Idempotency-Key is still in progress",
+		}, "")
+	default:
+		var headers http.Header
+		if err := json.Unmarshal([]byte(prior.Headers.String), &headers); err != nil {
+			respondWithAPIError(w, r, err, "idempotency key")
+			return
+		}
+		for name, values := range headers {
+			w.Header()[name] = values
+		}
+		w.Header().Set(idempotentReplayedHeader, "true")
+		w.WriteHeader(int(prior.Status.Int32))
+		w.Write(prior.Body)
+	}
+}
+
+func (cfg *ApiConfig) releaseIdempotencyKey(r *http.Request, scope, key string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), idempotencyStorageTimeout)
+	defer cancel()
+
+	err := cfg.DB.DeleteIdempotencyKey(ctx, database.DeleteIdempotencyKeyParams{
+		Scope: scope,
+		Key:   key,
+	})
+	if err != nil {
+		requestLogger(r).Error("error releasing idempotency key", "error", err)
+	}
+}
+
+// idempotencyScope keeps each caller's keys separate: authenticated users
+// by ID, anonymous callers by IP.
+func (cfg *ApiConfig) idempotencyScope(r *http.Request) string {
+	if userId, ok := r.Context().Value(userIDContextKey).(uuid.UUID); ok {
+		return "user:" + userId.String()
+	}
+	if userId, ok := cfg.viewerID(r); ok {
+		return "user:" + userId.String()
+	}
+	return "ip:" + clientIP(r)
+}
+
+// requestHash fingerprints what a retry must repeat exactly.
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func validIdempotencyKey(key string) bool {
+	if key == "" || len(key) > maxIdempotencyKeyLength {
+		return false
+	}
+	for _, c := range []byte(key) {
+		if c < 0x20 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// idempotencyRecorder tees a response so it can be stored. headers holds
+// only the headers the wrapped handler set, not ones added by outer
+// middleware for this particular request.
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	before  http.Header
+	headers http.Header
+	status  int
+	body    bytes.Buffer
+}
+
+func (rec *idempotencyRecorder) WriteHeader(code int) {
+	if rec.status == 0 {
+		rec.status = code
+		rec.headers = http.Header{}
+		for name, values := range rec.ResponseWriter.Header() {
+			if !slices.Equal(rec.before[name], values) {
+				rec.headers[name] = slices.Clone(values)
+			}
+		}
+	}
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+func (rec *idempotencyRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.WriteHeader(http.StatusOK)
+	}
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (rec *idempotencyRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+func (rec *idempotencyRecorder) Status() int {
+	if rec.status == 0 {
+		return http.StatusOK
+	}
+	return rec.status
+}
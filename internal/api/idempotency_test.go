@@ -0,0 +1,204 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ihyaulhaq/go-server/internal/database/memstore"
+)
+
+// doIdempotent is ts.do with an Idempotency-Key header.
+func (ts *testServer) doIdempotent(path, authorization, key string, body any) *http.Response {
+	ts.t.Helper()
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		ts.t.Fatal(err)
+	}
+	req, err := newIdempotentRequest(ts.server.URL+path, authorization, key, data)
+	if err != nil {
+		ts.t.Fatal(err)
+	}
+
+	resp, err := ts.server.Client().Do(req)
+	if err != nil {
+		ts.t.Fatal(err)
+	}
+	ts.t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func newIdempotentRequest(url, authorization, key string, body []byte) (*http.Request, error) {
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(idempotencyKeyHeader, key)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	return req, nil
+}
+
+func TestIdempotency_ReplaysChirp(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ts *testServer) {
+		alice := ts.createUser("alice@example.com")
+		bob := ts.createUser("bob@example.com")
+
+		first := ts.doIdempotent("/api/chirps", alice.bearer(), "k1", map[string]string{"body": "hello"})
+		expectStatus(t, first, http.StatusCreated)
+		if first.Header.Get(idempotentReplayedHeader) != "" {
+			t.Fatal("first response must not be marked as replayed")
+		}
+		var created ChirpsResponse
+		decodeBody(t, first, &created)
+
+		retry := ts.doIdempotent("/api/chirps", alice.bearer(), "k1", map[string]string{"body": "hello"})
+		expectStatus(t, retry, http.StatusCreated)
+		if retry.Header.Get(idempotentReplayedHeader) != "true" {
+			t.Fatal("expected retry to be marked as replayed")
+		}
+		if retry.Header.Get("Content-Type") != "application/json" {
+			t.Fatalf("expected stored content type, got %q", retry.Header.Get("Content-Type"))
+		}
+		if retry.Header.Get(requestIDHeader) == first.Header.Get(requestIDHeader) {
+			t.Fatal("request ID must not be replayed")
+		}
+		var replayed ChirpsResponse
+		decodeBody(t, retry, &replayed)
+		if replayed.ID != created.ID {
+			t.Fatalf("expected chirp %s to be replayed, got %s", created.ID, replayed.ID)
+		}
+
+		resp := ts.doIdempotent("/api/chirps", alice.bearer(), "k1", map[string]string{"body": "something else"})
+		expectProblem(t, resp, http.StatusUnprocessableEntity, CodeIdempotencyKeyReused)
+
+		// Keys are scoped to the caller.
+		resp = ts.doIdempotent("/api/chirps", bob.bearer(), "k1", map[string]string{"body": "hello"})
+		expectStatus(t, resp, http.StatusCreated)
+
+		if chirps := ts.listChirps("/api/chirps", ""); len(chirps) != 2 {
+			t.Fatalf("expected 2 chirps, got %d", len(chirps))
+		}
+	})
+}
+
+func TestIdempotency_ReplaysSignup(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ts *testServer) {
+		body := map[string]string{"email": "alice@example.com", "password": testPassword}
+
+		expectStatus(t, ts.doIdempotent("/api/users", "", "signup-1", body), http.StatusCreated)
+
+		// Without the key a retry would hit the unique email constraint.
+		expectStatus(t, ts.doIdempotent("/api/users", "", "signup-1", body), http.StatusCreated)
+		expectStatus(t, ts.do("POST", "/api/users", "", body), http.StatusConflict)
+
+		resp := ts.doIdempotent("/api/users", "", strings.Repeat("k", maxIdempotencyKeyLength+1), body)
+		expectProblem(t, resp, http.StatusBadRequest, CodeInvalidRequest)
+	})
+}
+
+func TestIdempotency_ConcurrentDuplicates(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ts *testServer) {
+		alice := ts.createUser("alice@example.com")
+		body := []byte(`{"body":"only once"}`)
+
+		const n = 8
+		var wg sync.WaitGroup
+		statuses := make([]int, n)
+		for i := range n {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				req, err := newIdempotentRequest(ts.server.URL+"/api/chirps", alice.bearer(), "race", body)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				resp, err := ts.server.Client().Do(req)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				resp.Body.Close()
+				statuses[i] = resp.StatusCode
+			}()
+		}
+		wg.Wait()
+
+		for _, status := range statuses {
+			if status != http.StatusCreated && status != http.StatusConflict {
+				t.Fatalf("unexpected status %d in %v", status, statuses)
+			}
+		}
+		if chirps := ts.listChirps("/api/chirps", ""); len(chirps) != 1 {
+			t.Fatalf("expected exactly 1 chirp, got %d", len(chirps))
+		}
+	})
+}
+
+func TestIdempotency_InFlightAndServerErrors(t *testing.T) {
+	cfg := &ApiConfig{DB: memstore.New(), SecretKey: testSecret, IdempotencyTTL: time.Hour}
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	var calls atomic.Int32
+	handler := cfg.MiddlewareIdempotency(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			respondWithError(w, r, http.StatusServiceUnavailable, "try again")
+			return
+		}
+		close(entered)
+		<-release
+		w.Header().Set("Location", "/api/things/1")
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, "created")
+	}))
+
+	post := func() *httptest.ResponseRecorder {
+		req, err := newIdempotentRequest("/api/things", "", "k", []byte(`{}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.RemoteAddr = "192.0.2.1:1234"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Server errors release the key instead of being replayed.
+	if rec := post(); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", rec.Code)
+	}
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- post() }()
+	<-entered
+
+	rec := post()
+	if rec.Code != http.StatusConflict || rec.Header().Get("Retry-After") != "1" {
+		t.Fatalf("expected 409 with Retry-After while in flight, got %d %v", rec.Code, rec.Header())
+	}
+
+	close(release)
+	if rec := <-done; rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", rec.Code)
+	}
+
+	rec = post()
+	if rec.Code != http.StatusCreated || rec.Body.String() != "created" || rec.Header().Get("Location") != "/api/things/1" {
+		t.Fatalf("expected stored response to be replayed, got %d %q %v", rec.Code, rec.Body.String(), rec.Header())
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("expected handler to run twice, ran %d times", calls.Load())
+	}
+}
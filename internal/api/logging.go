@@ -0,0 +1,80 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+const requestIDContextKey contextKey = "requestID"
+
+// validRequestID accepts caller-supplied IDs that are short and printable so
+// they are safe to echo back and to write into logs.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		if c < 0x21 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func requestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey).(string)
+	return id
+}
+
+// requestLogger returns the default logger annotated with the request ID and,
+// once MiddlewareAuth has run, the authenticated user ID.
+func requestLogger(r *http.Request) *slog.Logger {
+	logger := slog.Default()
+	if id := requestIDFromContext(r.Context()); id != "" {
+		logger = logger.With("request_id", id)
+	}
+	if userId, ok := r.Context().Value(userIDContextKey).(uuid.UUID); ok {
+		logger = logger.With("user_id", userId.String())
+	}
+	if sc := trace.SpanContextFromContext(r.Context()); sc.IsValid() {
+		logger = logger.With("trace_id", sc.TraceID().String())
+	}
+	return logger
+}
+
+// MiddlewareLogging assigns or propagates X-Request-ID and writes one access
+// log line per request.
+func MiddlewareLogging(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(requestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+		w.Header().Set(requestIDHeader, requestID)
+
+		ctx := context.WithValue(r.Context(), requestIDContextKey, requestID)
+		r = r.WithContext(ctx)
+
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+
+		requestLogger(r).Info("request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"route", r.Pattern,
+			"status", rec.Status(),
+			"latency_ms", float64(time.Since(start).Microseconds())/1000,
+			"bytes", rec.bytes,
+			"remote_ip", clientIP(r),
+			"user_agent", r.UserAgent(),
+		)
+	})
+}
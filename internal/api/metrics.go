@@ -3,23 +3,60 @@ package api
 import (
 	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 func (cfg *ApiConfig) MiddlewareMetricsInc(next http.Handler) http.Handler {
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		cfg.FileserverHits.Add(1)
+		cfg.Metrics.IncFileserverHits()
 		next.ServeHTTP(w, r)
 	})
 }
 
+// MiddlewareInstrument records request count, latency and in-flight requests
+// labelled by the ServeMux route pattern. It must wrap the mux itself, since
+// the pattern is only known once the mux has routed the request.
+func (cfg *ApiConfig) MiddlewareInstrument(next http.Handler) http.Handler {
+	if cfg.Metrics == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cfg.Metrics.InFlight.Inc()
+		defer cfg.Metrics.InFlight.Dec()
+
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+
+		route := r.Pattern
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(rec.Status())
+
+		cfg.Metrics.RequestsTotal.WithLabelValues(route, r.Method, status).Inc()
+		cfg.Metrics.RequestDuration.WithLabelValues(route, r.Method, status).Observe(time.Since(start).Seconds())
+	})
+}
+
 func (cfg *ApiConfig) HandlerMetrics(w http.ResponseWriter, r *http.Request) {
-	w.Header().Add("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Add("Content-Type", "text/html; charset=utf-8")
 	w.Write([]byte(
 		fmt.Sprintf(`<html>
 										<body>
 											<h1>Welcome, Chirpy Admin</h1>
 											<p>Chirpy has been visited %d times!</p>
+											<p>Chirps created: %d</p>
+											<p>Successful logins: %d</p>
+											<p>Failed logins: %d</p>
 										</body>
-									</html>`, cfg.FileserverHits.Load())))
+									</html>`,
+			int64(cfg.Metrics.CounterValue("chirpy_fileserver_hits_total")),
+			int64(cfg.Metrics.CounterValue("chirpy_chirps_created_total")),
+			int64(cfg.Metrics.LabeledCounterValue("chirpy_logins_total", "result", "success")),
+			int64(cfg.Metrics.LabeledCounterValue("chirpy_logins_total", "result", "failure")),
+		)))
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 
+	"github.com/google/uuid"
 	"github.com/ihyaulhaq/go-server/internal/auth"
 )
 
@@ -15,13 +16,24 @@ func (cfg *ApiConfig) MiddlewareAuth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		tokenStr, err := auth.GetBearerToken(r.Header)
 		if err != nil {
-			respondWithError(w, http.StatusUnauthorized, err.Error())
+			respondWithError(w, r, http.StatusUnauthorized, "missing bearer token")
 			return
 		}
 
 		userId, err := auth.ValidateJWt(tokenStr, cfg.SecretKey)
 		if err != nil {
-			respondWithError(w, http.StatusUnauthorized, err.Error())
+			respondWithError(w, r, http.StatusUnauthorized, "invalid or expired token")
+			return
+		}
+
+		user, err := cfg.DB.GetUserByID(r.Context(), userId)
+		if err != nil {
+			respondWithError(w, r, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+
+		if msg, restricted := accountRestriction(user); restricted {
+			respondWithAPIError(w, r, restrictedError(msg), "")
 			return
 		}
 
@@ -29,3 +41,19 @@ func (cfg *ApiConfig) MiddlewareAuth(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// viewerID returns the caller's user ID on public routes that personalise
+// their response when a valid access token is present.
+func (cfg *ApiConfig) viewerID(r *http.Request) (uuid.UUID, bool) {
+	tokenStr, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		return uuid.Nil, false
+	}
+
+	userId, err := auth.ValidateJWt(tokenStr, cfg.SecretKey)
+	if err != nil {
+		return uuid.Nil, false
+	}
+
+	return userId, true
+}
@@ -0,0 +1,223 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ihyaulhaq/go-server/internal/audit"
+	"github.com/ihyaulhaq/go-server/internal/auth"
+	"github.com/ihyaulhaq/go-server/internal/database"
+)
+
+type UserModerationResponse struct {
+	Id               uuid.UUID  `json:"id"`
+	Email            string     `json:"email"`
+	SuspendedUntil   *time.Time `json:"suspended_until"`
+	SuspensionReason string     `json:"suspension_reason"`
+	BannedAt         *time.Time `json:"banned_at"`
+	BanReason        string     `json:"ban_reason"`
+}
+
+func newUserModerationResponse(user database.User) UserModerationResponse {
+	response := UserModerationResponse{
+		Id:               user.ID,
+		Email:            user.Email,
+		SuspensionReason: user.SuspensionReason.String,
+		BanReason:        user.BanReason.String,
+	}
+	if user.SuspendedUntil.Valid {
+		response.SuspendedUntil = &user.SuspendedUntil.Time
+	}
+	if user.BannedAt.Valid {
+		response.BannedAt = &user.BannedAt.Time
+	}
+	return response
+}
+
+// accountRestriction reports why a user may not authenticate, if at all.
+func accountRestriction(user database.User) (string, bool) {
+	if msg, restricted := moderationRestriction(user); restricted {
+		return msg, true
+	}
+
+	if user.DeletionScheduledAt.Valid {
+		return "account scheduled for deletion: log in again to restore it", true
+	}
+
+	return "", false
+}
+
+// moderationRestriction reports a ban or an active suspension. Unlike
+// accountRestriction it ignores a scheduled deletion, which logging in
+// cancels.
+func moderationRestriction(user database.User) (string, bool) {
+	if user.BannedAt.Valid {
+		reason := user.BanReason.String
+		if reason == "" {
+			return "account banned", true
+		}
+		return fmt.Sprintf("account banned: %s", reason), true
+	}
+
+	if user.SuspendedUntil.Valid && time.Now().Before(user.SuspendedUntil.Time) {
+		msg := fmt.Sprintf("account suspended until %s", user.SuspendedUntil.Time.UTC().Format(time.RFC3339))
+		reason := user.SuspensionReason.String
+		if reason == "" {
+			return msg, true
+		}
+		return fmt.Sprintf("%s: %s", msg, reason), true
+	}
+
+	return "", false
+}
+
+func (cfg *ApiConfig) MiddlewareAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiKey, err := auth.GetApiKey(r.Header)
+		if err != nil {
+			respondWithError(w, r, http.StatusUnauthorized, "missing api key")
+			return
+		}
+
+		if cfg.AdminKey == "" || apiKey != cfg.AdminKey {
+			respondWithError(w, r, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// MiddlewareClientCert rejects requests that didn't present a client
+// certificate verified against the server's client CA, when
+// RequireClientCert is set. The TLS handshake only asks for certificates,
+// so routes without this middleware still accept clients without one.
+func (cfg *ApiConfig) MiddlewareClientCert(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if cfg.RequireClientCert && (r.TLS == nil || len(r.TLS.VerifiedChains) == 0) {
+			respondWithError(w, r, http.StatusForbidden, "client certificate required")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (cfg *ApiConfig) AdminFunc(
+	handler func(http.ResponseWriter, *http.Request),
+) http.Handler {
+	return cfg.MiddlewareAdmin(http.HandlerFunc(handler))
+}
+
+func (cfg *ApiConfig) HandleSuspendUser(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		SuspendedUntil time.Time `json:"suspended_until" validate:"required"`
+		Reason         string    `json:"reason" validate:"required"`
+	}
+
+	userId, err := parseUUIDParam("id", r.PathValue("id"))
+	if err != nil {
+		respondWithAPIError(w, r, err, "")
+		return
+	}
+
+	params, err := decodeJSON[parameters](w, r)
+	if err != nil {
+		respondWithAPIError(w, r, err, "")
+		return
+	}
+
+	v := &validator{}
+	v.check(params.SuspendedUntil.After(time.Now()), "suspended_until", "not_in_future", "must be in the future")
+	if err := v.err(); err != nil {
+		respondWithAPIError(w, r, err, "")
+		return
+	}
+
+	// The store also revokes the user's refresh tokens, atomically with
+	// the suspension, so a suspended user can't keep a session alive.
+	user, err := cfg.DB.SuspendUser(r.Context(), database.SuspendUserParams{
+		ID:               userId,
+		SuspendedUntil:   sql.NullTime{Time: params.SuspendedUntil.UTC(), Valid: true},
+		SuspensionReason: sql.NullString{String: params.Reason, Valid: true},
+	})
+	if err != nil {
+		respondWithAPIError(w, r, err, "user")
+		return
+	}
+
+	cfg.recordAudit(r, uuid.Nil, audit.ActionUserSuspend, user.ID.String())
+
+	respondWithJSON(w, 200, newUserModerationResponse(user))
+}
+
+func (cfg *ApiConfig) HandleUnsuspendUser(w http.ResponseWriter, r *http.Request) {
+	userId, err := parseUUIDParam("id", r.PathValue("id"))
+	if err != nil {
+		respondWithAPIError(w, r, err, "")
+		return
+	}
+
+	user, err := cfg.DB.UnsuspendUser(r.Context(), userId)
+	if err != nil {
+		respondWithAPIError(w, r, err, "user")
+		return
+	}
+
+	cfg.recordAudit(r, uuid.Nil, audit.ActionUserUnsuspend, user.ID.String())
+
+	respondWithJSON(w, 200, newUserModerationResponse(user))
+}
+
+func (cfg *ApiConfig) HandleBanUser(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Reason string `json:"reason" validate:"required"`
+	}
+
+	userId, err := parseUUIDParam("id", r.PathValue("id"))
+	if err != nil {
+		respondWithAPIError(w, r, err, "")
+		return
+	}
+
+	params, err := decodeJSON[parameters](w, r)
+	if err != nil {
+		respondWithAPIError(w, r, err, "")
+		return
+	}
+
+	// Like SuspendUser, this revokes the user's refresh tokens too.
+	user, err := cfg.DB.BanUser(r.Context(), database.BanUserParams{
+		ID:        userId,
+		BanReason: sql.NullString{String: params.Reason, Valid: true},
+	})
+	if err != nil {
+		respondWithAPIError(w, r, err, "user")
+		return
+	}
+
+	cfg.recordAudit(r, uuid.Nil, audit.ActionUserBan, user.ID.String())
+
+	respondWithJSON(w, 200, newUserModerationResponse(user))
+}
+
+func (cfg *ApiConfig) HandleUnbanUser(w http.ResponseWriter, r *http.Request) {
+	userId, err := parseUUIDParam("id", r.PathValue("id"))
+	if err != nil {
+		respondWithAPIError(w, r, err, "")
+		return
+	}
+
+	user, err := cfg.DB.UnbanUser(r.Context(), userId)
+	if err != nil {
+		respondWithAPIError(w, r, err, "user")
+		return
+	}
+
+	cfg.recordAudit(r, uuid.Nil, audit.ActionUserUnban, user.ID.String())
+
+	respondWithJSON(w, 200, newUserModerationResponse(user))
+}
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ihyaulhaq/go-server/internal/database/memstore"
+)
+
+func TestAdminRoutesRequireKey(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ts *testServer) {
+		alice := ts.createUser("alice@example.com")
+		path := "/admin/users/" + alice.ID.String() + "/ban"
+
+		resp := ts.do("POST", path, "", map[string]string{"reason": "spam"})
+		expectStatus(t, resp, http.StatusUnauthorized)
+
+		resp = ts.do("POST", path, "ApiKey wrong", map[string]string{"reason": "spam"})
+		expectStatus(t, resp, http.StatusUnauthorized)
+
+		resp = ts.do("GET", "/admin/audit", alice.bearer(), nil)
+		expectStatus(t, resp, http.StatusUnauthorized)
+	})
+}
+
+func TestAdminRoutesRequireClientCert(t *testing.T) {
+	ts := newTestServer(t, memstore.New())
+	ts.cfg.RequireClientCert = true
+
+	resp := ts.do("GET", "/admin/audit", "ApiKey "+testAdminKey, nil)
+	expectProblem(t, resp, http.StatusForbidden, CodeForbidden)
+	expectStatus(t, ts.do("GET", "/api/chirps", "", nil), http.StatusOK)
+
+	// A verified chain is what the TLS stack leaves behind once the
+	// client's certificate checks out against the client CA.
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	req := httptest.NewRequest("GET", "https://chirpy.example/admin/audit", nil)
+	req.TLS.VerifiedChains = [][]*x509.Certificate{{{}}}
+	rec := httptest.NewRecorder()
+	ts.cfg.MiddlewareClientCert(next).ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected a verified client to pass, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest("GET", "https://chirpy.example/admin/audit", nil)
+	req.TLS = &tls.ConnectionState{}
+	rec = httptest.NewRecorder()
+	ts.cfg.MiddlewareClientCert(next).ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected a TLS client without a certificate to be refused, got %d", rec.Code)
+	}
+}
+
+func TestSuspendUser(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ts *testServer) {
+		alice := ts.createUser("alice@example.com")
+		path := "/admin/users/" + alice.ID.String() + "/suspend"
+		admin := "ApiKey " + testAdminKey
+
+		resp := ts.do("POST", path, admin, map[string]any{
+			"suspended_until": time.Now().Add(-time.Hour),
+			"reason":          "spam",
+		})
+		expectStatus(t, resp, http.StatusBadRequest)
+
+		resp = ts.do("POST", path, admin, map[string]any{
+			"suspended_until": time.Now().Add(time.Hour),
+			"reason":          "spam",
+		})
+		expectStatus(t, resp, http.StatusOK)
+
+		var moderated UserModerationResponse
+		decodeBody(t, resp, &moderated)
+		if moderated.SuspendedUntil == nil || moderated.SuspensionReason != "spam" {
+			t.Fatalf("unexpected moderation response %+v", moderated)
+		}
+
+		// Existing access tokens stop working and refresh tokens are revoked.
+		expectStatus(t, ts.do("POST", "/api/chirps", alice.bearer(), map[string]string{"body": "hi"}), http.StatusForbidden)
+		expectStatus(t, ts.do("POST", "/api/refresh", "Bearer "+alice.RefreshToken, nil), http.StatusUnauthorized)
+
+		resp = ts.do("POST", "/api/login", "", map[string]string{
+			"email":    alice.Email,
+			"password": testPassword,
+		})
+		expectStatus(t, resp, http.StatusForbidden)
+
+		expectStatus(t, ts.do("DELETE", path, admin, nil), http.StatusOK)
+		ts.login(alice.Email)
+	})
+}
+
+func TestBanUser(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ts *testServer) {
+		alice := ts.createUser("alice@example.com")
+		path := "/admin/users/" + alice.ID.String() + "/ban"
+		admin := "ApiKey " + testAdminKey
+
+		expectStatus(t, ts.do("POST", path, admin, map[string]string{}), http.StatusBadRequest)
+		expectStatus(t, ts.do("POST", path, admin, map[string]string{"reason": "abuse"}), http.StatusOK)
+		expectStatus(t, ts.do("POST", "/api/refresh", "Bearer "+alice.RefreshToken, nil), http.StatusUnauthorized)
+
+		resp := ts.do("POST", "/api/login", "", map[string]string{
+			"email":    alice.Email,
+			"password": testPassword,
+		})
+		expectStatus(t, resp, http.StatusForbidden)
+
+		expectStatus(t, ts.do("DELETE", path, admin, nil), http.StatusOK)
+		ts.login(alice.Email)
+
+		missing := "/admin/users/00000000-0000-0000-0000-000000000001/ban"
+		expectStatus(t, ts.do("POST", missing, admin, map[string]string{"reason": "abuse"}), http.StatusNotFound)
+		expectStatus(t, ts.do("DELETE", "/admin/users/nope/ban", admin, nil), http.StatusBadRequest)
+	})
+}
+
+func TestModerationReasonsAreSeparate(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ts *testServer) {
+		alice := ts.createUser("alice@example.com")
+		base := "/admin/users/" + alice.ID.String()
+		admin := "ApiKey " + testAdminKey
+
+		expectStatus(t, ts.do("POST", base+"/ban", admin, map[string]string{"reason": "abuse"}), http.StatusOK)
+		expectStatus(t, ts.do("POST", base+"/suspend", admin, map[string]any{
+			"suspended_until": time.Now().Add(time.Hour),
+			"reason":          "spam",
+		}), http.StatusOK)
+
+		// Lifting the suspension must leave the ban and its reason alone.
+		resp := ts.do("DELETE", base+"/suspend", admin, nil)
+		expectStatus(t, resp, http.StatusOK)
+		var moderated UserModerationResponse
+		decodeBody(t, resp, &moderated)
+		if moderated.BannedAt == nil || moderated.BanReason != "abuse" || moderated.SuspensionReason != "" {
+			t.Fatalf("unexpected moderation response %+v", moderated)
+		}
+
+		problem := expectProblem(t, ts.do("POST", "/api/login", "", map[string]string{
+			"email":    alice.Email,
+			"password": testPassword,
+		}), http.StatusForbidden, CodeAccountRestricted)
+		if problem.Detail != "account banned: abuse" {
+			t.Fatalf("unexpected detail %q", problem.Detail)
+		}
+	})
+}
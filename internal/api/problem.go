@@ -0,0 +1,179 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/ihyaulhaq/go-server/internal/database"
+)
+
+// Stable, machine-readable error codes sent in the "code" member of every
+// problem response. Clients should branch on these rather than on detail,
+// which is for humans and may change.
+const (
+	CodeInvalidRequest         = "invalid_request"
+	CodeValidationFailed       = "validation_failed"
+	CodeUnauthorized           = "unauthorized"
+	CodeInvalidCredentials     = "invalid_credentials"
+	CodeForbidden              = "forbidden"
+	CodeAccountRestricted      = "account_restricted"
+	CodeNotFound               = "not_found"
+	CodeConflict               = "conflict"
+	CodeIdempotencyKeyReused   = "idempotency_key_reused"
+	CodeIdempotencyKeyInFlight = "idempotency_key_in_flight"
+	CodePreconditionFailed     = "precondition_failed"
+	CodePayloadTooLarge        = "payload_too_large"
+	CodeRateLimited            = "rate_limited"
+	CodeUnsupportedMediaType   = "unsupported_media_type"
+	CodeInternal               = "internal_error"
+)
+
+const (
+	problemContentType = "application/problem+json"
+	problemTypePrefix  = "urn:chirpy:problem:"
+
+	internalErrorDetail = "an internal error occurred"
+)
+
+// FieldError describes one invalid field in a request body or query.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// APIError is an error that knows how to render itself as a problem
+// response. Err is the internal cause; it is logged but never sent to the
+// client.
+type APIError struct {
+	Status int
+	Code   string
+	Detail string
+	Fields []FieldError
+	Err    error
+}
+
+func (e *APIError) Error() string {
+	if e.Err != nil {
+		return e.Detail + ": " + e.Err.Error()
+	}
+	return e.Detail
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// Problem is an RFC 7807 problem details document.
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// codeForStatus is the code used when a handler only supplies a status.
+func codeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeInvalidRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusPreconditionFailed:
+		return CodePreconditionFailed
+	}
+	if status >= 500 {
+		return CodeInternal
+	}
+	return CodeInvalidRequest
+}
+
+// dbError maps a storage error to an APIError. resource names the thing
+// being looked up or written, e.g. "user", and is used in the detail.
+func dbError(err error, resource string) *APIError {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return &APIError{Status: http.StatusNotFound, Code: CodeNotFound, Detail: resource + " not found", Err: err}
+	case database.IsUniqueViolation(err):
+		return &APIError{Status: http.StatusConflict, Code: CodeConflict, Detail: resource + " already exists", Err: err}
+	case database.IsForeignKeyViolation(err):
+		return &APIError{Status: http.StatusConflict, Code: CodeConflict, Detail: resource + " references a missing resource", Err: err}
+	}
+	return &APIError{Status: http.StatusInternalServerError, Code: CodeInternal, Err: err}
+}
+
+func validationError(fields ...FieldError) *APIError {
+	return &APIError{
+		Status: http.StatusBadRequest,
+		Code:   CodeValidationFailed,
+		Detail: "request validation failed",
+		Fields: fields,
+	}
+}
+
+// respondWithAPIError writes err as a problem response. Errors that are not
+// an *APIError are treated as storage errors about resource.
+func respondWithAPIError(w http.ResponseWriter, r *http.Request, err error, resource string) {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		apiErr = dbError(err, resource)
+	}
+
+	level := slog.LevelWarn
+	if apiErr.Status >= 500 {
+		level = slog.LevelError
+	}
+	requestLogger(r).Log(r.Context(), level, "responding with error",
+		"status", apiErr.Status,
+		"code", apiErr.Code,
+		"error", apiErr.Error(),
+		"method", r.Method,
+		"path", r.URL.Path,
+	)
+
+	problem := Problem{
+		Type:      problemTypePrefix + apiErr.Code,
+		Title:     http.StatusText(apiErr.Status),
+		Status:    apiErr.Status,
+		Detail:    apiErr.Detail,
+		Instance:  r.URL.Path,
+		Code:      apiErr.Code,
+		RequestID: requestIDFromContext(r.Context()),
+		Errors:    apiErr.Fields,
+	}
+
+	// Server-side failures never expose their cause.
+	if apiErr.Status >= 500 {
+		problem.Detail = internalErrorDetail
+	}
+
+	data, err := json.Marshal(problem)
+	if err != nil {
+		slog.Error("error marshalling problem", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(apiErr.Status)
+	w.Write(data)
+}
+
+// restrictedError is the 403 returned when accountRestriction or
+// moderationRestriction blocks a user.
+func restrictedError(msg string) *APIError {
+	return &APIError{Status: http.StatusForbidden, Code: CodeAccountRestricted, Detail: msg}
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func expectProblem(t *testing.T, resp *http.Response, status int, code string) Problem {
+	t.Helper()
+	expectStatus(t, resp, status)
+
+	if ct := resp.Header.Get("Content-Type"); ct != problemContentType {
+		t.Fatalf("expected content type %q, got %q", problemContentType, ct)
+	}
+
+	var problem Problem
+	decodeBody(t, resp, &problem)
+	if problem.Status != status || problem.Code != code {
+		t.Fatalf("expected %d %s, got %+v", status, code, problem)
+	}
+	if problem.Type != problemTypePrefix+code {
+		t.Fatalf("unexpected problem type %q", problem.Type)
+	}
+	return problem
+}
+
+func TestProblem_DuplicateEmail(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ts *testServer) {
+		alice := ts.createUser("alice@example.com")
+		ts.createUser("bob@example.com")
+
+		resp := ts.do("POST", "/api/users", "", map[string]string{
+			"email":    "alice@example.com",
+			"password": testPassword,
+		})
+		problem := expectProblem(t, resp, http.StatusConflict, CodeConflict)
+		if problem.Instance != "/api/users" {
+			t.Fatalf("unexpected instance %q", problem.Instance)
+		}
+
+		resp = ts.do("PUT", "/api/users", alice.bearer(), map[string]string{
+			"email":    "bob@example.com",
+			"password": testPassword,
+		})
+		expectProblem(t, resp, http.StatusConflict, CodeConflict)
+	})
+}
+
+func TestProblem_Codes(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ts *testServer) {
+		ts.createUser("alice@example.com")
+
+		resp := ts.do("POST", "/api/login", "", map[string]string{
+			"email":    "alice@example.com",
+			"password": "wrong",
+		})
+		expectProblem(t, resp, http.StatusUnauthorized, CodeInvalidCredentials)
+
+		resp = ts.do("POST", "/api/chirps", "Bearer not-a-jwt", map[string]string{"body": "hi"})
+		problem := expectProblem(t, resp, http.StatusUnauthorized, CodeUnauthorized)
+		if problem.RequestID == "" {
+			t.Fatal("expected request id in problem")
+		}
+	})
+}
+
+func TestProblem_HidesInternalErrors(t *testing.T) {
+	r := httptest.NewRequest("GET", "/api/chirps", nil)
+	rec := httptest.NewRecorder()
+
+	respondWithAPIError(rec, r, errors.New("pq: relation \"chirps\" does not exist"), "chirp")
+
+	resp := rec.Result()
+	problem := expectProblem(t, resp, http.StatusInternalServerError, CodeInternal)
+	if problem.Detail != internalErrorDetail || strings.Contains(problem.Detail, "pq") {
+		t.Fatalf("internal error leaked: %q", problem.Detail)
+	}
+}
+
+func TestValidationErrorFields(t *testing.T) {
+	r := httptest.NewRequest("POST", "/api/chirps", nil)
+	rec := httptest.NewRecorder()
+
+	respondWithAPIError(rec, r, validationError(FieldError{
+		Field:   "body",
+		Code:    "too_long",
+		Message: "must be at most 140 characters",
+	}), "")
+
+	problem := expectProblem(t, rec.Result(), http.StatusBadRequest, CodeValidationFailed)
+	if len(problem.Errors) != 1 || problem.Errors[0].Field != "body" {
+		t.Fatalf("unexpected field errors %+v", problem.Errors)
+	}
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+)
+
+// StartPurger hard-deletes soft-deleted chirps older than cfg.ChirpRetention,
+// accounts whose scheduled deletion is due, expired idempotency keys and
+// idle rate limit buckets, every interval until ctx is cancelled.
+func (cfg *ApiConfig) StartPurger(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		defer ticker.Stop()
+
+		for {
+			cfg.purgeDeletedChirps(ctx)
+			cfg.purgeScheduledUserDeletions(ctx)
+			cfg.purgeIdempotencyKeys(ctx)
+			cfg.purgeRateLimitBuckets(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (cfg *ApiConfig) purgeDeletedChirps(ctx context.Context) {
+	cutoff := time.Now().UTC().Add(-cfg.ChirpRetention)
+
+	rows, err := cfg.DB.PurgeDeletedChirps(ctx, sql.NullTime{Time: cutoff, Valid: true})
+	if err != nil {
+		slog.Error("error purging deleted chirps", "error", err)
+		return
+	}
+
+	if rows > 0 {
+		slog.Info("purged deleted chirps", "count", rows)
+	}
+}
+
+func (cfg *ApiConfig) purgeScheduledUserDeletions(ctx context.Context) {
+	now := time.Now().UTC()
+
+	rows, err := cfg.DB.PurgeScheduledUserDeletions(ctx, sql.NullTime{Time: now, Valid: true})
+	if err != nil {
+		slog.Error("error purging scheduled user deletions", "error", err)
+		return
+	}
+
+	if rows > 0 {
+		slog.Info("purged deleted users", "count", rows)
+	}
+}
+
+func (cfg *ApiConfig) purgeIdempotencyKeys(ctx context.Context) {
+	rows, err := cfg.DB.PurgeIdempotencyKeys(ctx, time.Now().UTC())
+	if err != nil {
+		slog.Error("error purging idempotency keys", "error", err)
+		return
+	}
+
+	if rows > 0 {
+		slog.Info("purged idempotency keys", "count", rows)
+	}
+}
+
+// purgeRateLimitBuckets drops database buckets idle for longer than the
+// longest policy period; by then they have refilled and carry no state.
+func (cfg *ApiConfig) purgeRateLimitBuckets(ctx context.Context) {
+	var longest time.Duration
+	for _, policy := range cfg.RateLimits {
+		longest = max(longest, policy.Period)
+	}
+	if longest == 0 {
+		return
+	}
+
+	rows, err := cfg.DB.PurgeRateLimitBuckets(ctx, time.Now().UTC().Add(-longest))
+	if err != nil {
+		slog.Error("error purging rate limit buckets", "error", err)
+		return
+	}
+
+	if rows > 0 {
+		slog.Info("purged rate limit buckets", "count", rows)
+	}
+}
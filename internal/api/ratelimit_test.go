@@ -0,0 +1,58 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ihyaulhaq/go-server/internal/ratelimit"
+)
+
+func TestRateLimit(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ts *testServer) {
+		ts.cfg.RateLimiter = ratelimit.DBStore{DB: ts.store}
+		ts.cfg.RateLimits = map[string]ratelimit.Policy{
+			"POST /api/login":  {Limit: 3, Period: time.Hour},
+			"POST /api/chirps": {Limit: 1, Period: time.Hour},
+		}
+
+		// createUser logs in, so this uses two of the IP's three logins.
+		alice := ts.createUser("alice@example.com")
+		bob := ts.createUser("bob@example.com")
+
+		resp := ts.do("POST", "/api/login", "", map[string]string{"email": "alice@example.com", "password": "wrong"})
+		expectStatus(t, resp, http.StatusUnauthorized)
+
+		resp = ts.do("POST", "/api/login", "", map[string]string{"email": "alice@example.com", "password": testPassword})
+		expectProblem(t, resp, http.StatusTooManyRequests, CodeRateLimited)
+
+		retryAfter, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+		if err != nil || retryAfter <= 0 || retryAfter > 1200 {
+			t.Fatalf("unexpected Retry-After %q", resp.Header.Get("Retry-After"))
+		}
+		if got := resp.Header.Get("RateLimit-Limit"); got != "3" {
+			t.Fatalf("unexpected RateLimit-Limit %q", got)
+		}
+		if got := resp.Header.Get("RateLimit-Remaining"); got != "0" {
+			t.Fatalf("unexpected RateLimit-Remaining %q", got)
+		}
+		if got := resp.Header.Get("RateLimit-Policy"); got != "3;w=3600" {
+			t.Fatalf("unexpected RateLimit-Policy %q", got)
+		}
+
+		// Authenticated callers get a bucket each.
+		ts.createChirp(alice, "first")
+		ts.createChirp(bob, "first")
+
+		resp = ts.do("POST", "/api/chirps", alice.bearer(), map[string]string{"body": "second"})
+		expectProblem(t, resp, http.StatusTooManyRequests, CodeRateLimited)
+
+		// Routes without a policy are not limited or annotated.
+		resp = ts.do("GET", "/api/chirps", "", nil)
+		expectStatus(t, resp, http.StatusOK)
+		if resp.Header.Get("RateLimit-Limit") != "" {
+			t.Fatal("unexpected rate limit headers on an unlimited route")
+		}
+	})
+}
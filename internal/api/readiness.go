@@ -1,9 +1,41 @@
 package api
 
-import "net/http"
+import (
+	"net/http"
 
-func HandlerReadiness(w http.ResponseWriter, r *http.Request) {
+	"github.com/ihyaulhaq/go-server/internal/health"
+)
+
+// StartDraining makes readiness report not-ready so load balancers stop
+// sending new requests while the server shuts down.
+func (cfg *ApiConfig) StartDraining() {
+	cfg.draining.Store(true)
+}
+
+// HandlerLiveness reports that the process is up and serving HTTP. It
+// deliberately checks no dependencies.
+func HandlerLiveness(w http.ResponseWriter, r *http.Request) {
 	w.Header().Add("Content-Type", "text/plain; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte(http.StatusText(http.StatusOK)))
 }
+
+// HandlerReadiness runs every configured health checker and reports each as
+// a JSON component. It fails while the server is draining.
+func (cfg *ApiConfig) HandlerReadiness(w http.ResponseWriter, r *http.Request) {
+	if cfg.draining.Load() {
+		respondWithJSON(w, http.StatusServiceUnavailable, health.Report{
+			Status:     "draining",
+			Components: map[string]health.ComponentStatus{},
+		})
+		return
+	}
+
+	report := health.Run(r.Context(), cfg.HealthCheckTimeout, cfg.HealthCheckers)
+
+	code := http.StatusOK
+	if report.Status != health.StatusOK {
+		code = http.StatusServiceUnavailable
+	}
+	respondWithJSON(w, code, report)
+}
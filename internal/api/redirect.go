@@ -0,0 +1,28 @@
+package api
+
+import (
+	"net"
+	"net/http"
+)
+
+// HTTPSRedirect answers every request with a permanent redirect to the
+// same URL over HTTPS on httpsPort. 308 keeps the method and body, so
+// clients that POST to the plain HTTP address aren't turned into GETs.
+func HTTPSRedirect(httpsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if host == "" {
+			respondWithError(w, r, http.StatusBadRequest, "missing host")
+			return
+		}
+		if httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		}
+
+		target := "https://" + host + r.URL.RequestURI()
+		http.Redirect(w, r, target, http.StatusPermanentRedirect)
+	})
+}
@@ -0,0 +1,32 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTPSRedirect(t *testing.T) {
+	tests := []struct {
+		port, host, path, want string
+	}{
+		{"443", "chirpy.example", "/api/chirps?sort=desc", "https://chirpy.example/api/chirps?sort=desc"},
+		{"443", "chirpy.example:80", "/app/", "https://chirpy.example/app/"},
+		{"8443", "chirpy.example:8080", "/admin/audit", "https://chirpy.example:8443/admin/audit"},
+		{"8443", "[::1]:8080", "/", "https://[::1]:8443/"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", tt.path, nil)
+		req.Host = tt.host
+		rec := httptest.NewRecorder()
+		HTTPSRedirect(tt.port).ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusPermanentRedirect {
+			t.Fatalf("%s%s: expected 308, got %d", tt.host, tt.path, rec.Code)
+		}
+		if got := rec.Header().Get("Location"); got != tt.want {
+			t.Fatalf("%s%s: expected redirect to %s, got %s", tt.host, tt.path, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/ihyaulhaq/go-server/internal/database"
+)
+
+// relationshipTarget resolves the caller and the {id} path user for the
+// block and mute endpoints, writing an error response when either is invalid.
+func (cfg *ApiConfig) relationshipTarget(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	userIDVal := r.Context().Value(userIDContextKey)
+	userId, ok := userIDVal.(uuid.UUID)
+	if !ok {
+		respondWithError(w, r, 401, "unauthorized")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	targetId, err := parseUUIDParam("id", r.PathValue("id"))
+	if err != nil {
+		respondWithAPIError(w, r, err, "")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	if targetId == userId {
+		respondWithError(w, r, 400, "cannot target yourself")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	if _, err := cfg.DB.GetUserByID(r.Context(), targetId); err != nil {
+		respondWithAPIError(w, r, err, "user")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userId, targetId, true
+}
+
+func (cfg *ApiConfig) HandleBlockUser(w http.ResponseWriter, r *http.Request) {
+	userId, targetId, ok := cfg.relationshipTarget(w, r)
+	if !ok {
+		return
+	}
+
+	err := cfg.DB.BlockUser(r.Context(), database.BlockUserParams{
+		BlockerID: userId,
+		BlockedID: targetId,
+	})
+	if err != nil {
+		respondWithError(w, r, 500, "something went wrong: cant block user")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *ApiConfig) HandleUnblockUser(w http.ResponseWriter, r *http.Request) {
+	userId, targetId, ok := cfg.relationshipTarget(w, r)
+	if !ok {
+		return
+	}
+
+	rows, err := cfg.DB.UnblockUser(r.Context(), database.UnblockUserParams{
+		BlockerID: userId,
+		BlockedID: targetId,
+	})
+	if err != nil {
+		respondWithError(w, r, 500, "something went wrong: cant unblock user")
+		return
+	}
+	if rows == 0 {
+		respondWithError(w, r, 404, "user is not blocked")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *ApiConfig) HandleMuteUser(w http.ResponseWriter, r *http.Request) {
+	userId, targetId, ok := cfg.relationshipTarget(w, r)
+	if !ok {
+		return
+	}
+
+	err := cfg.DB.MuteUser(r.Context(), database.MuteUserParams{
+		MuterID: userId,
+		MutedID: targetId,
+	})
+	if err != nil {
+		respondWithError(w, r, 500, "something went wrong: cant mute user")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *ApiConfig) HandleUnmuteUser(w http.ResponseWriter, r *http.Request) {
+	userId, targetId, ok := cfg.relationshipTarget(w, r)
+	if !ok {
+		return
+	}
+
+	rows, err := cfg.DB.UnmuteUser(r.Context(), database.UnmuteUserParams{
+		MuterID: userId,
+		MutedID: targetId,
+	})
+	if err != nil {
+		respondWithError(w, r, 500, "something went wrong: cant unmute user")
+		return
+	}
+	if rows == 0 {
+		respondWithError(w, r, 404, "user is not muted")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
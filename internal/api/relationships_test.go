@@ -0,0 +1,86 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestBlockUser(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ts *testServer) {
+		alice := ts.createUser("alice@example.com")
+		bob := ts.createUser("bob@example.com")
+		ts.createChirp(bob, "hello from bob")
+		path := "/api/users/" + bob.ID.String() + "/block"
+
+		expectStatus(t, ts.do("POST", path, alice.bearer(), nil), http.StatusNoContent)
+
+		if chirps := ts.listChirps("/api/chirps", alice.bearer()); len(chirps) != 0 {
+			t.Fatalf("expected blocked author to be hidden, got %d chirps", len(chirps))
+		}
+		if chirps := ts.listChirps("/api/chirps", ""); len(chirps) != 1 {
+			t.Fatalf("expected anonymous feed to be unaffected, got %d chirps", len(chirps))
+		}
+
+		expectStatus(t, ts.do("DELETE", path, alice.bearer(), nil), http.StatusNoContent)
+		expectStatus(t, ts.do("DELETE", path, alice.bearer(), nil), http.StatusNotFound)
+
+		if chirps := ts.listChirps("/api/chirps", alice.bearer()); len(chirps) != 1 {
+			t.Fatalf("expected unblocked author to be visible, got %d chirps", len(chirps))
+		}
+	})
+}
+
+func TestBlockHidesBlockerFromBlocked(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ts *testServer) {
+		alice := ts.createUser("alice@example.com")
+		bob := ts.createUser("bob@example.com")
+		chirp := ts.createChirp(alice, "hello from alice")
+
+		expectStatus(t, ts.do("POST", "/api/users/"+bob.ID.String()+"/block", alice.bearer(), nil), http.StatusNoContent)
+
+		if chirps := ts.listChirps("/api/chirps", bob.bearer()); len(chirps) != 0 {
+			t.Fatalf("expected the blocker to be hidden from the blocked user, got %d chirps", len(chirps))
+		}
+		if chirps := ts.listChirps("/api/chirps?author_id="+alice.ID.String(), bob.bearer()); len(chirps) != 0 {
+			t.Fatalf("expected the blocker to be hidden by author, got %d chirps", len(chirps))
+		}
+
+		path := "/api/chirps/" + chirp.ID.String()
+		expectStatus(t, ts.do("GET", path, bob.bearer(), nil), http.StatusNotFound)
+		expectStatus(t, ts.do("GET", path, "", nil), http.StatusOK)
+		expectStatus(t, ts.do("GET", path, alice.bearer(), nil), http.StatusOK)
+	})
+}
+
+func TestMuteUser(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ts *testServer) {
+		alice := ts.createUser("alice@example.com")
+		bob := ts.createUser("bob@example.com")
+		ts.createChirp(bob, "hello from bob")
+		path := "/api/users/" + bob.ID.String() + "/mute"
+
+		expectStatus(t, ts.do("POST", path, alice.bearer(), nil), http.StatusNoContent)
+
+		authorPath := "/api/chirps?author_id=" + bob.ID.String()
+		if chirps := ts.listChirps(authorPath, alice.bearer()); len(chirps) != 0 {
+			t.Fatalf("expected muted author to be hidden, got %d chirps", len(chirps))
+		}
+		if chirps := ts.listChirps(authorPath, bob.bearer()); len(chirps) != 1 {
+			t.Fatalf("expected mute to only affect the muter, got %d chirps", len(chirps))
+		}
+
+		expectStatus(t, ts.do("DELETE", path, alice.bearer(), nil), http.StatusNoContent)
+		expectStatus(t, ts.do("DELETE", path, alice.bearer(), nil), http.StatusNotFound)
+	})
+}
+
+func TestRelationshipTargetValidation(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ts *testServer) {
+		alice := ts.createUser("alice@example.com")
+
+		expectStatus(t, ts.do("POST", "/api/users/"+alice.ID.String()+"/block", alice.bearer(), nil), http.StatusBadRequest)
+		expectStatus(t, ts.do("POST", "/api/users/nope/mute", alice.bearer(), nil), http.StatusBadRequest)
+		expectStatus(t, ts.do("POST", "/api/users/00000000-0000-0000-0000-000000000001/block", alice.bearer(), nil), http.StatusNotFound)
+		expectStatus(t, ts.do("POST", "/api/users/"+alice.ID.String()+"/mute", "", nil), http.StatusUnauthorized)
+	})
+}
@@ -1,22 +1,41 @@
 package api
 
-import "net/http"
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/ihyaulhaq/go-server/internal/audit"
+	"github.com/ihyaulhaq/go-server/internal/database"
+)
+
+// resetData removes all users and everything they own. Audit events are
+// append-only and survive a reset.
+func resetData(ctx context.Context, db database.Store) error {
+	if err := db.DeleteRefreshTokens(ctx); err != nil {
+		return err
+	}
+	if err := db.DeleteChirps(ctx); err != nil {
+		return err
+	}
+	return db.DeleteUsers(ctx)
+}
 
 func (cfg *ApiConfig) HandlerReset(w http.ResponseWriter, r *http.Request) {
 	if cfg.Platform != "dev" {
-		respondWithError(w, http.StatusForbidden, "forbidden")
+		respondWithError(w, r, http.StatusForbidden, "forbidden")
 		return
 	}
 
-	if err := cfg.DB.DeleteUsers(r.Context()); err != nil {
-		respondWithError(w, 500, "something went wrong: cant delete users")
+	err := cfg.DB.WithTx(r.Context(), func(tx database.Store) error {
+		return resetData(r.Context(), tx)
+	})
+	if err != nil {
+		respondWithError(w, r, 500, "something went wrong: cant reset database")
 		return
 	}
 
-	if err := cfg.DB.DeleteChirps(r.Context()); err != nil {
-		respondWithError(w, 500, "something went wrong: cant delete chirps")
-		return
-	}
+	cfg.recordAudit(r, uuid.Nil, audit.ActionAdminReset, "all")
 
 	respondWithJSON(w, 200, map[string]string{
 		"message": "reset successful",
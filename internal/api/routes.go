@@ -0,0 +1,103 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// Routes registers every endpoint on a new mux, serving the web UI under
+// /app/ from site.
+func (cfg *ApiConfig) Routes(site *StaticSite) *http.ServeMux {
+	fsHandler := cfg.MiddlewareMetricsInc(http.StripPrefix("/app", site))
+
+	mux := http.NewServeMux()
+
+	// Every route gets CORS and security headers, goes through the rate
+	// limiter, which looks up its policy by the pattern registered here,
+	// and is compressed when the client allows it. Admin routes may also
+	// require a client certificate.
+	registered := map[string]bool{}
+	handle := func(pattern string, handler http.Handler) {
+		registered[pattern] = true
+		handler = cfg.MiddlewareRateLimit(MiddlewareCompress(handler))
+		if strings.Contains(pattern, " /admin/") {
+			handler = cfg.MiddlewareClientCert(handler)
+		}
+		mux.Handle(pattern, cfg.MiddlewareCORS(cfg.MiddlewareSecurityHeaders(handler)))
+	}
+	handleFunc := func(pattern string, handler http.HandlerFunc) {
+		handle(pattern, handler)
+	}
+
+	// Preflights for any route; the actual request is checked by
+	// MiddlewareCORS.
+	mux.Handle("OPTIONS /", cfg.MiddlewareSecurityHeaders(http.HandlerFunc(cfg.HandleCORSPreflight)))
+
+	handle("GET /app/", fsHandler)
+
+	handleFunc("GET /api/healthz", HandlerLiveness)
+	handleFunc("GET /api/livez", HandlerLiveness)
+	handleFunc("GET /api/readyz", cfg.HandlerReadiness)
+
+	handleFunc("GET /admin/metrics", cfg.HandlerMetrics)
+	handle("GET /metrics", cfg.Metrics.Handler())
+
+	handleFunc("POST /admin/reset", cfg.HandlerReset)
+	handleFunc("POST /admin/seed", cfg.HandlerSeed)
+
+	handle("GET /admin/audit", cfg.AdminFunc(cfg.HandleListAuditEvents))
+
+	handle("POST /admin/users/{id}/suspend", cfg.AdminFunc(cfg.HandleSuspendUser))
+	handle("DELETE /admin/users/{id}/suspend", cfg.AdminFunc(cfg.HandleUnsuspendUser))
+	handle("POST /admin/users/{id}/ban", cfg.AdminFunc(cfg.HandleBanUser))
+	handle("DELETE /admin/users/{id}/ban", cfg.AdminFunc(cfg.HandleUnbanUser))
+
+	handle("POST /api/users", cfg.MiddlewareIdempotency(http.HandlerFunc(cfg.HandleCreateUser)))
+	handleFunc("POST /api/login", cfg.HandleLogin)
+	handleFunc("POST /api/refresh", cfg.HandleRefreshToken)
+	handleFunc("POST /api/revoke", cfg.HandleRevokeToken)
+	handle(
+		"PUT /api/users",
+		cfg.ProtectedFunc(cfg.HandleEditUser),
+	)
+
+	handle("GET /api/users/me/export", cfg.ProtectedFunc(cfg.HandleExportUser))
+	handle("DELETE /api/users/me", cfg.ProtectedFunc(cfg.HandleDeleteMe))
+
+	handle("POST /api/users/{id}/block", cfg.ProtectedFunc(cfg.HandleBlockUser))
+	handle("DELETE /api/users/{id}/block", cfg.ProtectedFunc(cfg.HandleUnblockUser))
+	handle("POST /api/users/{id}/mute", cfg.ProtectedFunc(cfg.HandleMuteUser))
+	handle("DELETE /api/users/{id}/mute", cfg.ProtectedFunc(cfg.HandleUnmuteUser))
+
+	handle("POST /api/chirps",
+		cfg.MiddlewareAuth(cfg.MiddlewareIdempotency(http.HandlerFunc(cfg.HandleCreateChirps))),
+	)
+	handleFunc("GET /api/chirps", cfg.HandleGetChirps)
+	handleFunc("GET /api/chirps/stream", cfg.HandleChirpStream)
+	handleFunc("GET /api/chirps/{id}", cfg.HandleGetChirp)
+	handle(
+		"DELETE /api/chirps/{id}",
+		cfg.ProtectedFunc(cfg.HandleDeleteChirp),
+	)
+
+	handle(
+		"POST /api/chirps/{id}/restore",
+		cfg.ProtectedFunc(cfg.HandleRestoreChirp),
+	)
+
+	handleFunc("POST /api/polka/webhooks", cfg.HandleUpgradeUserToChirpyRed)
+
+	for pattern := range cfg.RateLimits {
+		if !registered[pattern] {
+			slog.Warn("rate limit configured for unknown route", "route", pattern)
+		}
+	}
+	for pattern := range cfg.RouteSecurity {
+		if !registered[pattern] {
+			slog.Warn("security headers configured for unknown route", "route", pattern)
+		}
+	}
+
+	return mux
+}
@@ -0,0 +1,176 @@
+package api
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ihyaulhaq/go-server/internal/audit"
+	"github.com/ihyaulhaq/go-server/internal/auth"
+	"github.com/ihyaulhaq/go-server/internal/database"
+)
+
+//go:embed fixtures/seed.json
+var defaultSeedFixture []byte
+
+// seedFixture is the QA data set loaded by POST /admin/seed. IDs and
+// timestamps are fixed so that every seed produces the same database.
+type seedFixture struct {
+	Users []struct {
+		ID          uuid.UUID `json:"id"`
+		Email       string    `json:"email"`
+		Password    string    `json:"password"`
+		IsChirpyRed bool      `json:"is_chirpy_red"`
+		CreatedAt   time.Time `json:"created_at"`
+	} `json:"users"`
+	Chirps []struct {
+		ID        uuid.UUID `json:"id"`
+		UserID    uuid.UUID `json:"user_id"`
+		Body      string    `json:"body"`
+		CreatedAt time.Time `json:"created_at"`
+	} `json:"chirps"`
+	Blocks []struct {
+		BlockerID uuid.UUID `json:"blocker_id"`
+		BlockedID uuid.UUID `json:"blocked_id"`
+	} `json:"blocks"`
+	Mutes []struct {
+		MuterID uuid.UUID `json:"muter_id"`
+		MutedID uuid.UUID `json:"muted_id"`
+	} `json:"mutes"`
+	RefreshTokens []struct {
+		Token     string    `json:"token"`
+		UserID    uuid.UUID `json:"user_id"`
+		ExpiresAt time.Time `json:"expires_at"`
+	} `json:"refresh_tokens"`
+}
+
+// loadSeedFixture reads SeedFile, or the embedded fixture when it is unset.
+func (cfg *ApiConfig) loadSeedFixture() (seedFixture, error) {
+	data := defaultSeedFixture
+	if cfg.SeedFile != "" {
+		var err error
+		data, err = os.ReadFile(cfg.SeedFile)
+		if err != nil {
+			return seedFixture{}, fmt.Errorf("reading seed file: %w", err)
+		}
+	}
+
+	var fixture seedFixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return seedFixture{}, fmt.Errorf("parsing seed file: %w", err)
+	}
+	return fixture, nil
+}
+
+// seedData replaces the database contents with fixture. hashes holds the
+// password hash for each fixture user, by index.
+func seedData(ctx context.Context, db database.Store, fixture seedFixture, hashes []string) error {
+	if err := resetData(ctx, db); err != nil {
+		return err
+	}
+
+	for i, u := range fixture.Users {
+		_, err := db.InsertUser(ctx, database.InsertUserParams{
+			ID:             u.ID,
+			CreatedAt:      u.CreatedAt.UTC(),
+			Email:          u.Email,
+			HashedPassword: hashes[i],
+			IsChirpyRed:    u.IsChirpyRed,
+		})
+		if err != nil {
+			return fmt.Errorf("user %s: %w", u.Email, err)
+		}
+	}
+
+	for _, c := range fixture.Chirps {
+		_, err := db.InsertChirp(ctx, database.InsertChirpParams{
+			ID:        c.ID,
+			CreatedAt: c.CreatedAt.UTC(),
+			Body:      c.Body,
+			UserID:    c.UserID,
+		})
+		if err != nil {
+			return fmt.Errorf("chirp %s: %w", c.ID, err)
+		}
+	}
+
+	for _, b := range fixture.Blocks {
+		err := db.BlockUser(ctx, database.BlockUserParams{
+			BlockerID: b.BlockerID,
+			BlockedID: b.BlockedID,
+		})
+		if err != nil {
+			return fmt.Errorf("block %s -> %s: %w", b.BlockerID, b.BlockedID, err)
+		}
+	}
+
+	for _, m := range fixture.Mutes {
+		err := db.MuteUser(ctx, database.MuteUserParams{
+			MuterID: m.MuterID,
+			MutedID: m.MutedID,
+		})
+		if err != nil {
+			return fmt.Errorf("mute %s -> %s: %w", m.MuterID, m.MutedID, err)
+		}
+	}
+
+	for _, t := range fixture.RefreshTokens {
+		_, err := db.CreateRefreshToken(ctx, database.CreateRefreshTokenParams{
+			Token:     t.Token,
+			UserID:    t.UserID,
+			ExpiresAt: t.ExpiresAt.UTC(),
+		})
+		if err != nil {
+			return fmt.Errorf("refresh token for %s: %w", t.UserID, err)
+		}
+	}
+
+	return nil
+}
+
+func (cfg *ApiConfig) HandlerSeed(w http.ResponseWriter, r *http.Request) {
+	if cfg.Platform != "dev" {
+		respondWithError(w, r, http.StatusForbidden, "forbidden")
+		return
+	}
+
+	fixture, err := cfg.loadSeedFixture()
+	if err != nil {
+		respondWithAPIError(w, r, err, "seed fixture")
+		return
+	}
+
+	// Hash up front so the transaction isn't held open for the slow part.
+	hashes := make([]string, len(fixture.Users))
+	for i, u := range fixture.Users {
+		hashes[i], err = auth.HashPassword(u.Password)
+		if err != nil {
+			respondWithError(w, r, 500, "something went wrong: cant hash password")
+			return
+		}
+	}
+
+	err = cfg.DB.WithTx(r.Context(), func(tx database.Store) error {
+		return seedData(r.Context(), tx, fixture, hashes)
+	})
+	if err != nil {
+		// A broken fixture is a server fault, whatever the constraint says.
+		respondWithAPIError(w, r, &APIError{Status: 500, Code: CodeInternal, Detail: "cant seed database", Err: err}, "")
+		return
+	}
+
+	cfg.recordAudit(r, uuid.Nil, audit.ActionAdminSeed, "all")
+
+	respondWithJSON(w, 200, map[string]int{
+		"users":          len(fixture.Users),
+		"chirps":         len(fixture.Chirps),
+		"blocks":         len(fixture.Blocks),
+		"mutes":          len(fixture.Mutes),
+		"refresh_tokens": len(fixture.RefreshTokens),
+	})
+}
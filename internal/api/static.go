@@ -0,0 +1,243 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+// cacheControlImmutable is for content-hashed asset URLs, whose bytes can
+// never change.
+const cacheControlImmutable = "public, max-age=31536000, immutable"
+
+// assetsDir holds the files served at content-hashed URLs.
+const assetsDir = "assets"
+
+// precompressedExts maps content codings to the file extension of their
+// precompressed siblings, e.g. app.js.br next to app.js.
+var precompressedExts = map[string]string{
+	encodingBrotli: ".br",
+	encodingGzip:   ".gz",
+}
+
+// StaticSite serves the web UI under /app/ from a file system holding
+// index.html and assets/. Every asset is also reachable at a URL with its
+// content hash in the name, which index.html gets from its "asset" template
+// func and which may be cached forever. Paths without an extension that
+// match no file get index.html, so client-side routes survive a reload.
+type StaticSite struct {
+	fsys fs.FS
+	live bool
+	snap *siteSnapshot
+}
+
+// siteSnapshot is everything derived from the files: asset hashes and the
+// rendered index page.
+type siteSnapshot struct {
+	hashes    map[string]string // asset path -> content hash
+	byHashed  map[string]string // hashed asset path -> asset path
+	index     []byte
+	indexETag string
+}
+
+// NewStaticSite serves fsys. With live set the files are re-read on every
+// request, for serving a working copy from disk during development;
+// otherwise they are read once here and errors surface at startup.
+func NewStaticSite(fsys fs.FS, live bool) (*StaticSite, error) {
+	s := &StaticSite{fsys: fsys, live: live}
+	if live {
+		return s, nil
+	}
+
+	snap, err := loadSite(fsys)
+	if err != nil {
+		return nil, err
+	}
+	s.snap = snap
+	return s, nil
+}
+
+func loadSite(fsys fs.FS) (*siteSnapshot, error) {
+	snap := &siteSnapshot{
+		hashes:   map[string]string{},
+		byHashed: map[string]string{},
+	}
+
+	err := fs.WalkDir(fsys, assetsDir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || isPrecompressed(name) {
+			return err
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(data)
+		hash := hex.EncodeToString(sum[:6])
+		snap.hashes[name] = hash
+		snap.byHashed[hashedName(name, hash)] = name
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("hashing static assets: %w", err)
+	}
+
+	src, err := fs.ReadFile(fsys, "index.html")
+	if err != nil {
+		return nil, fmt.Errorf("reading index.html: %w", err)
+	}
+	tmpl, err := template.New("index.html").Funcs(template.FuncMap{
+		"asset": func(name string) (string, error) {
+			hash, ok := snap.hashes[name]
+			if !ok {
+				return "", fmt.Errorf("unknown asset %q", name)
+			}
+			return "/app/" + hashedName(name, hash), nil
+		},
+	}).Parse(string(src))
+	if err != nil {
+		return nil, fmt.Errorf("parsing index.html: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, nil); err != nil {
+		return nil, fmt.Errorf("rendering index.html: %w", err)
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	snap.index = buf.Bytes()
+	snap.indexETag = `"` + hex.EncodeToString(sum[:16]) + `"`
+	return snap, nil
+}
+
+// hashedName inserts hash before the extension: assets/logo.png becomes
+// assets/logo.<hash>.png.
+func hashedName(name, hash string) string {
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + hash + ext
+}
+
+func isPrecompressed(name string) bool {
+	ext := path.Ext(name)
+	for _, e := range precompressedExts {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *StaticSite) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	snap := s.snap
+	if s.live {
+		var err error
+		if snap, err = loadSite(s.fsys); err != nil {
+			respondWithAPIError(w, r, &APIError{Status: http.StatusInternalServerError, Code: CodeInternal, Err: err}, "")
+			return
+		}
+	}
+
+	name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	switch {
+	case name == "" || name == "index.html":
+		serveIndex(w, r, snap)
+	case snap.byHashed[name] != "":
+		asset := snap.byHashed[name]
+		w.Header().Set("Cache-Control", cacheControlImmutable)
+		serveAsset(w, r, s.fsys, asset, snap.hashes[asset])
+	case snap.hashes[name] != "":
+		w.Header().Set("Cache-Control", cacheControlPublic)
+		serveAsset(w, r, s.fsys, name, snap.hashes[name])
+	case path.Ext(name) == "":
+		serveIndex(w, r, snap)
+	default:
+		respondWithError(w, r, http.StatusNotFound, "file not found")
+	}
+}
+
+func serveIndex(w http.ResponseWriter, r *http.Request, snap *siteSnapshot) {
+	h := w.Header()
+	h.Set("Cache-Control", cacheControlPublic)
+	h.Set("Content-Type", "text/html; charset=utf-8")
+	h.Set("ETag", snap.indexETag)
+	http.ServeContent(w, r, "index.html", time.Time{}, bytes.NewReader(snap.index))
+}
+
+// serveAsset serves name, or a precompressed sibling the client accepts,
+// validated by the asset's content hash.
+func serveAsset(w http.ResponseWriter, r *http.Request, fsys fs.FS, name, hash string) {
+	w.Header().Set("ETag", `"`+hash+`"`)
+	if serveFileVariant(w, r, fsys, name) {
+		return
+	}
+
+	f, err := fsys.Open(name)
+	if err != nil {
+		respondWithError(w, r, http.StatusNotFound, "file not found")
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	content, ok := f.(io.ReadSeeker)
+	if err != nil || !ok {
+		respondWithAPIError(w, r, &APIError{Status: http.StatusInternalServerError, Code: CodeInternal, Err: err}, "")
+		return
+	}
+	http.ServeContent(w, r, name, info.ModTime(), content)
+}
+
+// serveFileVariant serves the precompressed sibling of name best matching
+// the request's Accept-Encoding, reporting whether it did.
+func serveFileVariant(w http.ResponseWriter, r *http.Request, fsys fs.FS, name string) bool {
+	var available []string
+	for _, encoding := range supportedEncodings {
+		if info, err := fs.Stat(fsys, name+precompressedExts[encoding]); err == nil && info.Mode().IsRegular() {
+			available = append(available, encoding)
+		}
+	}
+	if len(available) == 0 {
+		return false
+	}
+
+	// The file has variants, so caches must key on Accept-Encoding even
+	// when this client gets the plain one.
+	w.Header().Add("Vary", "Accept-Encoding")
+
+	encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"), available)
+	if encoding == "" {
+		return false
+	}
+
+	f, err := fsys.Open(name + precompressedExts[encoding])
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		return false
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Encoding", encoding)
+	http.ServeContent(w, r, name, info.ModTime(), content)
+	return true
+}
@@ -0,0 +1,199 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/ihyaulhaq/go-server/web"
+)
+
+func testSiteFS() fstest.MapFS {
+	return fstest.MapFS{
+		"index.html":           {Data: []byte(`<img src="{{asset "assets/logo.png"}}"><h1>Welcome to Chirpy</h1>`)},
+		"assets/logo.png":      {Data: []byte("png")},
+		"assets/app.js":        {Data: []byte("console.log(1)")},
+		"assets/app.js.gz":     {Data: []byte("gzip js")},
+		"assets/app.js.br":     {Data: []byte("brotli js")},
+		"assets/docs/help.txt": {Data: []byte("help")},
+	}
+}
+
+// serveSite sends a GET for path, relative to /app/, straight to site.
+func serveSite(site *StaticSite, path string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", "/"+path, nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	site.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestStaticSite(t *testing.T) {
+	site, err := NewStaticSite(testSiteFS(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logo := "assets/logo." + site.snap.hashes["assets/logo.png"] + ".png"
+
+	rec := serveSite(site, "", nil)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `src="/app/`+logo+`"`) {
+		t.Fatalf("expected index linking to %s, got %d %q", logo, rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get("Cache-Control") != cacheControlPublic || rec.Header().Get("ETag") == "" {
+		t.Fatalf("expected index to be revalidated, got %v", rec.Header())
+	}
+
+	rec = serveSite(site, "", map[string]string{"If-None-Match": rec.Header().Get("ETag")})
+	if rec.Code != http.StatusNotModified {
+		t.Fatalf("expected 304 for unchanged index, got %d", rec.Code)
+	}
+
+	rec = serveSite(site, logo, nil)
+	if rec.Code != http.StatusOK || rec.Body.String() != "png" {
+		t.Fatalf("expected hashed logo, got %d %q", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Cache-Control"); got != cacheControlImmutable {
+		t.Fatalf("expected hashed asset to be immutable, got %q", got)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "image/png" {
+		t.Fatalf("expected image/png, got %q", got)
+	}
+
+	rec = serveSite(site, "assets/logo.png", nil)
+	if rec.Code != http.StatusOK || rec.Header().Get("Cache-Control") != cacheControlPublic {
+		t.Fatalf("expected plain asset URL to be revalidated, got %d %v", rec.Code, rec.Header())
+	}
+
+	for _, path := range []string{"chirps", "users/42/settings"} {
+		rec = serveSite(site, path, nil)
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Welcome to Chirpy") {
+			t.Fatalf("expected SPA fallback for %s, got %d", path, rec.Code)
+		}
+	}
+
+	for _, path := range []string{"assets/missing.js", "assets/logo.0000.png", "assets/app.js.gz", "go.mod", ".env"} {
+		if rec = serveSite(site, path, nil); rec.Code != http.StatusNotFound {
+			t.Fatalf("expected 404 for %s, got %d", path, rec.Code)
+		}
+	}
+
+	rec = serveSite(site, "assets/", nil)
+	if strings.Contains(rec.Body.String(), "logo.png") {
+		t.Fatal("directories must not be listed")
+	}
+}
+
+func TestStaticSite_Precompressed(t *testing.T) {
+	site, err := NewStaticSite(testSiteFS(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path, accept   string
+		body, encoding string
+		vary           bool
+	}{
+		{"assets/app.js", "gzip, br", "brotli js", "br", true},
+		{"assets/app.js", "br;q=0.5, gzip", "gzip js", "gzip", true},
+		{"assets/app.js", "", "console.log(1)", "", true},
+		{"assets/app." + site.snap.hashes["assets/app.js"] + ".js", "gzip", "gzip js", "gzip", true},
+		{"assets/logo.png", "gzip", "png", "", false},
+		{"assets/docs/help.txt", "br", "help", "", false},
+	}
+
+	for _, tt := range tests {
+		rec := serveSite(site, tt.path, map[string]string{"Accept-Encoding": tt.accept})
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s (%q): status %d", tt.path, tt.accept, rec.Code)
+		}
+		if rec.Body.String() != tt.body || rec.Header().Get("Content-Encoding") != tt.encoding {
+			t.Errorf("%s (%q): got %q encoded %q, want %q encoded %q",
+				tt.path, tt.accept, rec.Body.String(), rec.Header().Get("Content-Encoding"), tt.body, tt.encoding)
+		}
+		if got := rec.Header().Get("Vary") == "Accept-Encoding"; got != tt.vary {
+			t.Errorf("%s (%q): Vary %q", tt.path, tt.accept, rec.Header().Get("Vary"))
+		}
+	}
+
+	// Through the middleware the variant gets its own strong ETag, and
+	// revalidating with it works.
+	handler := MiddlewareCompress(site)
+	req := httptest.NewRequest("GET", "/assets/app.js", nil)
+	req.Header.Set("Accept-Encoding", "br")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	etag := rec.Header().Get("ETag")
+	if !strings.HasSuffix(etag, `-br"`) {
+		t.Fatalf("expected brotli ETag, got %q", etag)
+	}
+
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotModified || rec.Header().Get("ETag") != etag {
+		t.Fatalf("expected 304 with %s, got %d %q", etag, rec.Code, rec.Header().Get("ETag"))
+	}
+}
+
+func TestStaticSite_LiveFromDisk(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("index.html", "<h1>v1</h1>")
+	write("assets/style.css", "h1{}")
+	write(".env", "SECRET=hunter2")
+	write("main.go", "package main")
+
+	site, err := NewStaticSite(web.DirFS(dir), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rec := serveSite(site, "", nil); rec.Body.String() != "<h1>v1</h1>" {
+		t.Fatalf("unexpected index %q", rec.Body.String())
+	}
+
+	write("index.html", "<h1>v2</h1>")
+	if rec := serveSite(site, "", nil); rec.Body.String() != "<h1>v2</h1>" {
+		t.Fatalf("expected edited index to be served, got %q", rec.Body.String())
+	}
+
+	for _, path := range []string{".env", "main.go"} {
+		if rec := serveSite(site, path, nil); rec.Code != http.StatusNotFound {
+			t.Fatalf("expected %s outside the allowlist to 404, got %d", path, rec.Code)
+		}
+	}
+	if _, err := web.DirFS(dir).Open(".env"); err == nil {
+		t.Fatal("expected DirFS to refuse files outside the allowlist")
+	}
+}
+
+func TestStaticSite_Embedded(t *testing.T) {
+	site, err := NewStaticSite(web.FS, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := serveSite(site, "", nil)
+	body, _ := io.ReadAll(rec.Body)
+	if rec.Code != http.StatusOK || !strings.Contains(string(body), "Welcome to Chirpy") {
+		t.Fatalf("unexpected embedded index: %d %q", rec.Code, body)
+	}
+}
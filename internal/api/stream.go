@@ -0,0 +1,142 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ihyaulhaq/go-server/internal/pubsub"
+)
+
+const (
+	// streamKeepAlive is how often an idle stream sends a comment, so
+	// proxies don't time it out and dead clients are noticed.
+	streamKeepAlive = 15 * time.Second
+	// streamWriteTimeout bounds each write to a stream. It replaces the
+	// server's WriteTimeout, which would otherwise end every stream.
+	streamWriteTimeout = 10 * time.Second
+	// streamRetry is the reconnection delay suggested to clients.
+	streamRetry = 3 * time.Second
+)
+
+// ChirpDeletedEvent is the data of a chirp_deleted event.
+type ChirpDeletedEvent struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+// publishChirpEvent tells stream subscribers about a change. The change
+// is already committed, so a failure is logged rather than returned.
+func (cfg *ApiConfig) publishChirpEvent(r *http.Request, eventType string, at time.Time, chirpID, authorID uuid.UUID, data any) {
+	if cfg.Events == nil {
+		return
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		requestLogger(r).Error("error marshalling chirp event", "error", err)
+		return
+	}
+
+	event := pubsub.Event{
+		ID:       pubsub.NewID(at, chirpID.String()),
+		Type:     eventType,
+		AuthorID: authorID,
+		Data:     payload,
+	}
+	// Publish even if the client hangs up as soon as it has its response.
+	if err := cfg.Events.Publish(context.WithoutCancel(r.Context()), event); err != nil {
+		requestLogger(r).Error("error publishing chirp event", "error", err, "type", eventType)
+	}
+}
+
+// HandleChirpStream sends chirp_created and chirp_deleted events as
+// Server-Sent Events, optionally only for one author_id. Clients that
+// reconnect with Last-Event-ID first get the events they missed, as far
+// back as the hub remembers.
+//
+// Like GET /api/chirps, a signed-in viewer doesn't see chirps from users
+// they muted or blocked, or who blocked them. That set is read when the
+// stream opens, so changes to it apply once the client reconnects.
+func (cfg *ApiConfig) HandleChirpStream(w http.ResponseWriter, r *http.Request) {
+	var authorID uuid.UUID
+	if s := r.URL.Query().Get("author_id"); s != "" {
+		id, err := parseUUIDParam("author_id", s)
+		if err != nil {
+			respondWithAPIError(w, r, err, "")
+			return
+		}
+		authorID = id
+	}
+
+	hidden := map[uuid.UUID]bool{}
+	if viewerID, ok := cfg.viewerID(r); ok {
+		ids, err := cfg.DB.GetHiddenAuthorIDs(r.Context(), viewerID)
+		if err != nil {
+			respondWithAPIError(w, r, err, "")
+			return
+		}
+		for _, id := range ids {
+			hidden[id] = true
+		}
+	}
+
+	sub, missed := cfg.EventHub.Subscribe(r.Header.Get("Last-Event-ID"))
+	defer sub.Close()
+
+	rc := http.NewResponseController(w)
+	write := func(format string, args ...any) bool {
+		// Not every writer supports deadlines; the server's WriteTimeout
+		// then applies.
+		_ = rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+		if _, err := fmt.Fprintf(w, format, args...); err != nil {
+			return false
+		}
+		return rc.Flush() == nil
+	}
+	send := func(event pubsub.Event) bool {
+		if (authorID != uuid.Nil && event.AuthorID != authorID) || hidden[event.AuthorID] {
+			return true
+		}
+		return write("id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-store")
+	// Stop nginx and similar proxies from buffering the stream.
+	h.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if !write("retry: %d\n\n", streamRetry.Milliseconds()) {
+		return
+	}
+	for _, event := range missed {
+		if !send(event) {
+			return
+		}
+	}
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if !write(": keep-alive\n\n") {
+				return
+			}
+		case event, ok := <-sub.C:
+			// The hub closed the subscription because it is shutting
+			// down or this client fell behind; the client reconnects.
+			if !ok || !send(event) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ihyaulhaq/go-server/internal/database/memstore"
+	"github.com/ihyaulhaq/go-server/internal/pubsub"
+)
+
+type sseEvent struct {
+	ID, Type, Data string
+}
+
+// openStream connects to the chirp stream and returns its events. The
+// subscription is registered by the time it returns.
+func (ts *testServer) openStream(path string, headers map[string]string) (*http.Response, <-chan sseEvent) {
+	ts.t.Helper()
+
+	resp := ts.doWithHeaders("GET", path, "", headers)
+	expectStatus(ts.t, resp, http.StatusOK)
+
+	events := make(chan sseEvent, 16)
+	go func() {
+		defer close(events)
+		var event sseEvent
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			field, value, _ := strings.Cut(scanner.Text(), ": ")
+			switch field {
+			case "id":
+				event.ID = value
+			case "event":
+				event.Type = value
+			case "data":
+				event.Data = value
+			case "":
+				if event.Type != "" {
+					events <- event
+				}
+				event = sseEvent{}
+			}
+		}
+	}()
+	return resp, events
+}
+
+func nextEvent(t *testing.T, events <-chan sseEvent) sseEvent {
+	t.Helper()
+	select {
+	case event, ok := <-events:
+		if !ok {
+			t.Fatal("stream ended")
+		}
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for an event")
+	}
+	return sseEvent{}
+}
+
+func TestChirpStream(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ts *testServer) {
+		alice := ts.createUser("alice@example.com")
+		bob := ts.createUser("bob@example.com")
+
+		resp, events := ts.openStream("/api/chirps/stream?author_id="+alice.ID.String(), map[string]string{"Accept-Encoding": "gzip"})
+		if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+			t.Fatalf("unexpected content type %q", ct)
+		}
+		if resp.Header.Get("Content-Encoding") != "" {
+			t.Fatal("streams must not be compressed")
+		}
+
+		ts.createChirp(bob, "not for alice's followers")
+		chirp := ts.createChirp(alice, "hello stream")
+
+		event := nextEvent(t, events)
+		if event.Type != pubsub.TypeChirpCreated || event.ID == "" {
+			t.Fatalf("unexpected event %+v", event)
+		}
+		var created ChirpsResponse
+		if err := json.Unmarshal([]byte(event.Data), &created); err != nil {
+			t.Fatal(err)
+		}
+		if created.ID != chirp.ID || created.Body != "hello stream" {
+			t.Fatalf("expected alice's chirp, got %+v", created)
+		}
+
+		expectStatus(t, ts.do("DELETE", "/api/chirps/"+chirp.ID.String(), alice.bearer(), nil), http.StatusOK)
+		event = nextEvent(t, events)
+		var deleted ChirpDeletedEvent
+		if err := json.Unmarshal([]byte(event.Data), &deleted); err != nil {
+			t.Fatal(err)
+		}
+		if event.Type != pubsub.TypeChirpDeleted || deleted.ID != chirp.ID || deleted.UserID != alice.ID {
+			t.Fatalf("unexpected delete event %+v", event)
+		}
+	})
+}
+
+func TestChirpStream_HidesMutedAndBlocked(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ts *testServer) {
+		alice := ts.createUser("alice@example.com")
+		bob := ts.createUser("bob@example.com")
+		carol := ts.createUser("carol@example.com")
+		dave := ts.createUser("dave@example.com")
+		erin := ts.createUser("erin@example.com")
+		expectStatus(t, ts.do("POST", "/api/users/"+bob.ID.String()+"/mute", alice.bearer(), nil), http.StatusNoContent)
+		expectStatus(t, ts.do("POST", "/api/users/"+carol.ID.String()+"/block", alice.bearer(), nil), http.StatusNoContent)
+		expectStatus(t, ts.do("POST", "/api/users/"+alice.ID.String()+"/block", erin.bearer(), nil), http.StatusNoContent)
+
+		_, events := ts.openStream("/api/chirps/stream", map[string]string{"Authorization": alice.bearer()})
+		_, anonymous := ts.openStream("/api/chirps/stream", nil)
+
+		ts.createChirp(bob, "muted")
+		ts.createChirp(carol, "blocked")
+		ts.createChirp(erin, "blocker")
+		ts.createChirp(dave, "visible")
+
+		var chirp ChirpsResponse
+		if err := json.Unmarshal([]byte(nextEvent(t, events).Data), &chirp); err != nil {
+			t.Fatal(err)
+		}
+		if chirp.Body != "visible" {
+			t.Fatalf("expected muted, blocked and blocking authors to be skipped, got %q", chirp.Body)
+		}
+		for _, want := range []string{"muted", "blocked", "blocker", "visible"} {
+			if err := json.Unmarshal([]byte(nextEvent(t, anonymous).Data), &chirp); err != nil {
+				t.Fatal(err)
+			}
+			if chirp.Body != want {
+				t.Fatalf("expected anonymous streams to be unfiltered, got %q", chirp.Body)
+			}
+		}
+	})
+}
+
+func TestChirpStream_Resume(t *testing.T) {
+	ts := newTestServer(t, memstore.New())
+	alice := ts.createUser("alice@example.com")
+
+	resp, events := ts.openStream("/api/chirps/stream", nil)
+	ts.createChirp(alice, "one")
+	first := nextEvent(t, events)
+	resp.Body.Close()
+
+	ts.createChirp(alice, "two")
+	ts.createChirp(alice, "three")
+
+	_, events = ts.openStream("/api/chirps/stream", map[string]string{"Last-Event-ID": first.ID})
+	for _, want := range []string{"two", "three"} {
+		var chirp ChirpsResponse
+		if err := json.Unmarshal([]byte(nextEvent(t, events).Data), &chirp); err != nil {
+			t.Fatal(err)
+		}
+		if chirp.Body != want {
+			t.Fatalf("expected missed chirp %q, got %q", want, chirp.Body)
+		}
+	}
+}
+
+func TestChirpStream_EndsOnShutdown(t *testing.T) {
+	ts := newTestServer(t, memstore.New())
+
+	resp, events := ts.openStream("/api/chirps/stream", nil)
+	ts.cfg.EventHub.Close()
+
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Fatal("expected no events")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the stream to end when the hub closes")
+	}
+	if _, err := io.ReadAll(resp.Body); err != nil {
+		t.Fatalf("expected a clean end of stream, got %v", err)
+	}
+}
+
+func TestChirpStream_InvalidAuthor(t *testing.T) {
+	ts := newTestServer(t, memstore.New())
+	expectProblem(t, ts.do("GET", "/api/chirps/stream?author_id=nope", "", nil), http.StatusBadRequest, CodeValidationFailed)
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/ihyaulhaq/go-server/internal/api"
+
+type matchedRouteKey struct{}
+
+// matchedRoute carries the pattern the mux matched back out to
+// MiddlewareTracing. The mux sets Pattern only on the request it is given,
+// and any middleware in between that calls WithContext hands it a copy, so
+// the outer request never sees it.
+type matchedRoute struct {
+	pattern string
+}
+
+// RecordRoute wraps the mux and reports the pattern it matched to
+// MiddlewareTracing, however far up the chain that is.
+func RecordRoute(mux http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.ServeHTTP(w, r)
+		if route, ok := r.Context().Value(matchedRouteKey{}).(*matchedRoute); ok {
+			route.pattern = r.Pattern
+		}
+	})
+}
+
+// MiddlewareTracing starts a server span for every request, continuing any
+// trace passed in a W3C traceparent header. The span is renamed to the
+// matched route pattern, which RecordRoute reports from around the mux.
+func MiddlewareTracing(next http.Handler) http.Handler {
+	tracer := otel.Tracer(tracerName)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("url.path", r.URL.Path),
+				attribute.String("client.address", clientIP(r)),
+				attribute.String("user_agent.original", r.UserAgent()),
+			),
+		)
+		defer span.End()
+
+		route := &matchedRoute{}
+		ctx = context.WithValue(ctx, matchedRouteKey{}, route)
+		r = r.WithContext(ctx)
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+
+		if route.pattern != "" {
+			span.SetName(route.pattern)
+			span.SetAttributes(attribute.String("http.route", route.pattern))
+		}
+
+		status := rec.Status()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= 500 {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	})
+}
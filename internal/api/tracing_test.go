@@ -0,0 +1,60 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddlewareTracing_RouteName(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/chirps/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	// The same chain as main, where MiddlewareLogging hands the mux a copy
+	// of the request.
+	handler := MiddlewareTracing(MiddlewareLogging(RecordRoute(mux)))
+
+	for _, path := range []string{"/api/chirps/00000000-0000-0000-0000-000000000001", "/nowhere"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+
+	routed := spans[0]
+	if routed.Name() != "GET /api/chirps/{id}" {
+		t.Fatalf("expected the span to be named after the route, got %q", routed.Name())
+	}
+	if !hasAttribute(routed.Attributes(), attribute.String("http.route", "GET /api/chirps/{id}")) {
+		t.Fatalf("expected http.route, got %v", routed.Attributes())
+	}
+	if !hasAttribute(routed.Attributes(), attribute.Int("http.response.status_code", http.StatusNotFound)) {
+		t.Fatalf("expected the status code, got %v", routed.Attributes())
+	}
+
+	if unmatched := spans[1]; unmatched.Name() != "GET" {
+		t.Fatalf("expected unmatched requests to keep the method name, got %q", unmatched.Name())
+	}
+}
+
+func hasAttribute(attrs []attribute.KeyValue, want attribute.KeyValue) bool {
+	for _, attr := range attrs {
+		if attr == want {
+			return true
+		}
+	}
+	return false
+}
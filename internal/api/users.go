@@ -1,13 +1,11 @@
 package api
 
 import (
-	"database/sql"
-	"encoding/json"
-	"errors"
 	"net/http"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/ihyaulhaq/go-server/internal/audit"
 	"github.com/ihyaulhaq/go-server/internal/auth"
 	"github.com/ihyaulhaq/go-server/internal/database"
 )
@@ -32,26 +30,19 @@ type UserLoginResponse struct {
 
 func (cfg *ApiConfig) HandleCreateUser(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
+		Email    string `json:"email" validate:"required,email"`
+		Password string `json:"password" validate:"required"`
 	}
 
-	decoder := json.NewDecoder(r.Body)
-	params := parameters{}
-	err := decoder.Decode(&params)
+	params, err := decodeJSON[parameters](w, r)
 	if err != nil {
-		respondWithError(w, 400, "invalid request payload")
-		return
-	}
-
-	if params.Email == "" || params.Password == "" {
-		respondWithError(w, 400, "email and password are required")
+		respondWithAPIError(w, r, err, "")
 		return
 	}
 
 	params.Password, err = auth.HashPassword(params.Password)
 	if err != nil {
-		respondWithError(w, 500, "something went wrong: cant hash password")
+		respondWithError(w, r, 500, "something went wrong: cant hash password")
 		return
 	}
 
@@ -61,7 +52,7 @@ func (cfg *ApiConfig) HandleCreateUser(w http.ResponseWriter, r *http.Request) {
 	})
 
 	if err != nil {
-		respondWithError(w, 500, "something went wrong: cant create user")
+		respondWithAPIError(w, r, err, "user")
 		return
 	}
 
@@ -79,44 +70,60 @@ func (cfg *ApiConfig) HandleCreateUser(w http.ResponseWriter, r *http.Request) {
 
 func (cfg *ApiConfig) HandleLogin(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
+		Email    string `json:"email" validate:"required"`
+		Password string `json:"password" validate:"required"`
 	}
 
-	decoder := json.NewDecoder(r.Body)
-	params := parameters{}
-	err := decoder.Decode(&params)
+	params, err := decodeJSON[parameters](w, r)
 	if err != nil {
-		respondWithError(w, 400, "invalid request payload")
-		return
-	}
-
-	if params.Email == "" || params.Password == "" {
-		respondWithError(w, 400, "email and password are required")
+		respondWithAPIError(w, r, err, "")
 		return
 	}
 
 	user, err := cfg.DB.GetUserByEmail(r.Context(), params.Email)
 	if err != nil {
-		respondWithError(w, 404, "user not found")
+		cfg.recordAudit(r, uuid.Nil, audit.ActionLoginFailed, params.Email)
+		cfg.Metrics.ObserveLogin(false)
+		respondWithError(w, r, 404, "user not found")
 		return
 	}
 
 	match, err := auth.CheckPasswordHash(params.Password, user.HashedPassword)
 	if err != nil {
-		respondWithError(w, 500, "something went wrong: cant check password hash")
+		respondWithError(w, r, 500, "something went wrong: cant check password hash")
 		return
 	}
 
 	if !match {
-		respondWithError(w, 401, "unauthorized: invalid credentials")
+		cfg.recordAudit(r, user.ID, audit.ActionLoginFailed, params.Email)
+		cfg.Metrics.ObserveLogin(false)
+		respondWithAPIError(w, r, &APIError{
+			Status: http.StatusUnauthorized,
+			Code:   CodeInvalidCredentials,
+			Detail: "invalid email or password",
+		}, "")
 		return
 	}
 
+	if msg, restricted := moderationRestriction(user); restricted {
+		respondWithAPIError(w, r, restrictedError(msg), "")
+		return
+	}
+
+	// Logging in again during the cooling-off period keeps the account.
+	// A refused login above leaves the deletion scheduled.
+	if user.DeletionScheduledAt.Valid {
+		user, err = cfg.DB.CancelUserDeletion(r.Context(), user.ID)
+		if err != nil {
+			respondWithError(w, r, 500, "something went wrong: cant cancel account deletion")
+			return
+		}
+	}
+
 	expiresIn := time.Hour
 	token, err := auth.MakeJWT(user.ID, cfg.SecretKey, expiresIn)
 	if err != nil {
-		respondWithError(w, 500, "could not create token")
+		respondWithError(w, r, 500, "could not create token")
 		return
 	}
 
@@ -124,7 +131,7 @@ func (cfg *ApiConfig) HandleLogin(w http.ResponseWriter, r *http.Request) {
 	refreshKey, err := auth.MakeRefreshToken()
 	expiresAt := time.Now().UTC().Add(refreshTokenTTL)
 	if err != nil {
-		respondWithError(w, 500, err.Error())
+		respondWithError(w, r, 500, "could not create refresh token")
 		return
 	}
 	refreshToken, err := cfg.DB.CreateRefreshToken(r.Context(), database.CreateRefreshTokenParams{
@@ -133,10 +140,13 @@ func (cfg *ApiConfig) HandleLogin(w http.ResponseWriter, r *http.Request) {
 		ExpiresAt: expiresAt,
 	})
 	if err != nil {
-		respondWithError(w, 500, "cant create refresh token")
+		respondWithError(w, r, 500, "cant create refresh token")
 		return
 	}
 
+	cfg.recordAudit(r, user.ID, audit.ActionLogin, user.ID.String())
+	cfg.Metrics.ObserveLogin(true)
+
 	response := UserLoginResponse{
 		Id:           user.ID,
 		CreatedAt:    user.CreatedAt,
@@ -154,25 +164,36 @@ func (cfg *ApiConfig) HandleLogin(w http.ResponseWriter, r *http.Request) {
 func (cfg *ApiConfig) HandleRefreshToken(w http.ResponseWriter, r *http.Request) {
 	refreshToken, err := auth.GetBearerToken(r.Header)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "missing refresh token")
+		respondWithError(w, r, http.StatusUnauthorized, "missing refresh token")
 		return
 	}
 
 	tokenRecord, err := cfg.DB.GetRefreshToken(r.Context(), refreshToken)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "invalid refresh token")
+		respondWithError(w, r, http.StatusUnauthorized, "invalid refresh token")
 		return
 	}
 
 	// Check if revoked
 	if tokenRecord.RevokedAt.Valid {
-		respondWithError(w, http.StatusUnauthorized, "token revoked")
+		respondWithError(w, r, http.StatusUnauthorized, "token revoked")
 		return
 	}
 
 	// Check expiration
 	if time.Now().After(tokenRecord.ExpiresAt) {
-		respondWithError(w, http.StatusUnauthorized, "token expired")
+		respondWithError(w, r, http.StatusUnauthorized, "token expired")
+		return
+	}
+
+	user, err := cfg.DB.GetUserByID(r.Context(), tokenRecord.UserID)
+	if err != nil {
+		respondWithError(w, r, http.StatusUnauthorized, "invalid refresh token")
+		return
+	}
+
+	if msg, restricted := accountRestriction(user); restricted {
+		respondWithAPIError(w, r, restrictedError(msg), "")
 		return
 	}
 
@@ -183,7 +204,7 @@ func (cfg *ApiConfig) HandleRefreshToken(w http.ResponseWriter, r *http.Request)
 		time.Hour,
 	)
 	if err != nil {
-		respondWithError(w, 500, "could not create access token")
+		respondWithError(w, r, 500, "could not create access token")
 		return
 	}
 
@@ -200,47 +221,47 @@ func (cfg *ApiConfig) HandleRefreshToken(w http.ResponseWriter, r *http.Request)
 func (cfg *ApiConfig) HandleRevokeToken(w http.ResponseWriter, r *http.Request) {
 	refreshToken, err := auth.GetBearerToken(r.Header)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "missing refresh token")
+		respondWithError(w, r, http.StatusUnauthorized, "missing refresh token")
 		return
 	}
 
+	var actorId uuid.UUID
+	if tokenRecord, err := cfg.DB.GetRefreshToken(r.Context(), refreshToken); err == nil {
+		actorId = tokenRecord.UserID
+	}
+
 	err = cfg.DB.RevokeRefreshToken(r.Context(), refreshToken)
 	if err != nil {
-		respondWithError(w, 500, "could not revoke token")
+		respondWithError(w, r, 500, "could not revoke token")
 		return
 	}
+
+	cfg.recordAudit(r, actorId, audit.ActionTokenRevoke, "refresh_token")
 	w.WriteHeader(http.StatusNoContent)
 }
 
 func (cfg *ApiConfig) HandleEditUser(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
+		Email    string `json:"email" validate:"required,email"`
+		Password string `json:"password" validate:"required"`
 	}
 
-	decoder := json.NewDecoder(r.Body)
-	params := parameters{}
-	err := decoder.Decode(&params)
+	params, err := decodeJSON[parameters](w, r)
 	if err != nil {
-		respondWithError(w, 400, "invalid request payload")
-		return
-	}
-
-	if params.Email == "" || params.Password == "" {
-		respondWithError(w, 400, "email and password are required")
+		respondWithAPIError(w, r, err, "")
 		return
 	}
 
 	params.Password, err = auth.HashPassword(params.Password)
 	if err != nil {
-		respondWithError(w, 500, "something went wrong: cant hash password")
+		respondWithError(w, r, 500, "something went wrong: cant hash password")
 		return
 	}
 
 	userIDVal := r.Context().Value(userIDContextKey)
 	userId, ok := userIDVal.(uuid.UUID)
 	if !ok {
-		respondWithError(w, 401, "unauthorized")
+		respondWithError(w, r, 401, "unauthorized")
 		return
 	}
 
@@ -250,10 +271,12 @@ func (cfg *ApiConfig) HandleEditUser(w http.ResponseWriter, r *http.Request) {
 		ID:             userId,
 	})
 	if err != nil {
-		respondWithError(w, 500, err.Error())
+		respondWithAPIError(w, r, err, "user")
 		return
 	}
 
+	cfg.recordAudit(r, userId, audit.ActionUserUpdate, userId.String())
+
 	response := UserResponse{
 		Id:          newUser.ID,
 		CreatedAt:   newUser.CreatedAt,
@@ -271,25 +294,24 @@ func (cfg *ApiConfig) HandleUpgradeUserToChirpyRed(w http.ResponseWriter, r *htt
 	}
 
 	type parameters struct {
-		Event string      `json:"event"`
+		Event string      `json:"event" validate:"required"`
 		Data  upgradeData `json:"data"`
 	}
 
-	decoder := json.NewDecoder(r.Body)
-	params := parameters{}
-	err := decoder.Decode(&params)
-	if err != nil {
-		respondWithError(w, 400, err.Error())
-		return
-	}
 	apiKey, err := auth.GetApiKey(r.Header)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, err.Error())
+		respondWithError(w, r, http.StatusUnauthorized, "missing api key")
 		return
 	}
 
 	if apiKey != cfg.PolkaKey {
-		respondWithError(w, http.StatusUnauthorized, "unauthorized")
+		respondWithError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	params, err := decodeJSON[parameters](w, r)
+	if err != nil {
+		respondWithAPIError(w, r, err, "")
 		return
 	}
 
@@ -298,14 +320,21 @@ func (cfg *ApiConfig) HandleUpgradeUserToChirpyRed(w http.ResponseWriter, r *htt
 		return
 	}
 
+	if params.Data.UserID == uuid.Nil {
+		respondWithAPIError(w, r, validationError(FieldError{
+			Field:   "data.user_id",
+			Code:    "required",
+			Message: "is required",
+		}), "")
+		return
+	}
+
 	_, err = cfg.DB.UpgradeUserToChirpyRed(r.Context(), params.Data.UserID)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			respondWithError(w, 404, err.Error())
-			return
-		}
-		respondWithError(w, 500, err.Error())
+		respondWithAPIError(w, r, err, "user")
 		return
 	}
+
+	cfg.recordAudit(r, uuid.Nil, audit.ActionUserUpgrade, params.Data.UserID.String())
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestCreateUser(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ts *testServer) {
+
+		resp := ts.do("POST", "/api/users", "", map[string]string{
+			"email":    "alice@example.com",
+			"password": testPassword,
+		})
+		expectStatus(t, resp, http.StatusCreated)
+
+		var user UserResponse
+		decodeBody(t, resp, &user)
+		if user.Email != "alice@example.com" || user.IsChirpyRed {
+			t.Fatalf("unexpected user %+v", user)
+		}
+
+		resp = ts.do("POST", "/api/users", "", map[string]string{"email": "bob@example.com"})
+		expectStatus(t, resp, http.StatusBadRequest)
+	})
+}
+
+func TestLogin(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ts *testServer) {
+		alice := ts.createUser("alice@example.com")
+
+		if alice.Token == "" || alice.RefreshToken == "" {
+			t.Fatal("expected access and refresh tokens")
+		}
+
+		resp := ts.do("POST", "/api/login", "", map[string]string{
+			"email":    "alice@example.com",
+			"password": "wrong",
+		})
+		expectStatus(t, resp, http.StatusUnauthorized)
+
+		resp = ts.do("POST", "/api/login", "", map[string]string{
+			"email":    "nobody@example.com",
+			"password": testPassword,
+		})
+		expectStatus(t, resp, http.StatusNotFound)
+	})
+}
+
+func TestRefreshAndRevoke(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ts *testServer) {
+		alice := ts.createUser("alice@example.com")
+
+		resp := ts.do("POST", "/api/refresh", "Bearer "+alice.RefreshToken, nil)
+		expectStatus(t, resp, http.StatusOK)
+
+		var refreshed struct {
+			Token string `json:"token"`
+		}
+		decodeBody(t, resp, &refreshed)
+		if refreshed.Token == "" {
+			t.Fatal("expected a new access token")
+		}
+
+		resp = ts.do("POST", "/api/revoke", "Bearer "+alice.RefreshToken, nil)
+		expectStatus(t, resp, http.StatusNoContent)
+
+		resp = ts.do("POST", "/api/refresh", "Bearer "+alice.RefreshToken, nil)
+		expectStatus(t, resp, http.StatusUnauthorized)
+
+		resp = ts.do("POST", "/api/refresh", "Bearer not-a-token", nil)
+		expectStatus(t, resp, http.StatusUnauthorized)
+	})
+}
+
+func TestEditUser(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ts *testServer) {
+		alice := ts.createUser("alice@example.com")
+
+		resp := ts.do("PUT", "/api/users", alice.bearer(), map[string]string{
+			"email":    "alice@example.org",
+			"password": testPassword,
+		})
+		expectStatus(t, resp, http.StatusOK)
+
+		var user UserResponse
+		decodeBody(t, resp, &user)
+		if user.Email != "alice@example.org" {
+			t.Fatalf("expected updated email, got %s", user.Email)
+		}
+
+		ts.login("alice@example.org")
+
+		resp = ts.do("PUT", "/api/users", "", map[string]string{
+			"email":    "mallory@example.com",
+			"password": testPassword,
+		})
+		expectStatus(t, resp, http.StatusUnauthorized)
+	})
+}
+
+func TestUpgradeWebhook(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ts *testServer) {
+		alice := ts.createUser("alice@example.com")
+
+		event := map[string]any{
+			"event": "user.upgraded",
+			"data":  map[string]string{"user_id": alice.ID.String()},
+		}
+
+		resp := ts.do("POST", "/api/polka/webhooks", "ApiKey wrong", event)
+		expectStatus(t, resp, http.StatusUnauthorized)
+
+		resp = ts.do("POST", "/api/polka/webhooks", "ApiKey "+testPolkaKey, event)
+		expectStatus(t, resp, http.StatusNoContent)
+
+		user, err := ts.store.GetUserByID(context.Background(), alice.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !user.IsChirpyRed {
+			t.Fatal("expected user to be upgraded")
+		}
+
+		resp = ts.do("POST", "/api/polka/webhooks", "ApiKey "+testPolkaKey, map[string]any{
+			"event": "user.upgraded",
+			"data":  map[string]string{"user_id": "00000000-0000-0000-0000-000000000001"},
+		})
+		expectStatus(t, resp, http.StatusNotFound)
+
+		resp = ts.do("POST", "/api/polka/webhooks", "ApiKey "+testPolkaKey, map[string]any{
+			"event": "user.something_else",
+		})
+		expectStatus(t, resp, http.StatusNoContent)
+	})
+}
@@ -0,0 +1,193 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// validator collects field errors so a response can report all of them.
+// tagErr records a malformed `validate` tag, which is a bug in the request
+// struct rather than in the request.
+type validator struct {
+	fields []FieldError
+	tagErr error
+}
+
+func (v *validator) add(field, code, msg string) {
+	v.fields = append(v.fields, FieldError{Field: field, Code: code, Message: msg})
+}
+
+// check records a field error unless ok holds. Handlers use it for rules
+// that do not fit a tag, such as comparisons against the current time.
+func (v *validator) check(ok bool, field, code, msg string) {
+	if !ok {
+		v.add(field, code, msg)
+	}
+}
+
+func (v *validator) err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	return validationError(v.fields...)
+}
+
+// validateStruct applies the `validate` tags on s, which must be a struct.
+// Request structs declare their rules in a `validate` tag, for example
+//
+//	Email string `json:"email" validate:"required,email"`
+//
+// Supported rules:
+//
+//	required  the field must not be its zero value
+//	email     a bare address such as alice@example.com
+//	min=N     at least N characters (runes, not bytes)
+//	max=N     at most N characters
+//	uuid      a canonical UUID string
+//
+// Rules other than required are skipped for empty strings. Nested structs
+// are validated too and reported with a dotted path, e.g. "data.user_id".
+// An unknown rule or a bad argument fails every request with a 500, even
+// when the field is empty, so a typo shows up on the first request.
+func validateStruct(s any) error {
+	v := &validator{}
+	v.walk("", reflect.ValueOf(s))
+	if v.tagErr != nil {
+		return &APIError{Status: http.StatusInternalServerError, Code: CodeInternal, Err: v.tagErr}
+	}
+	return v.err()
+}
+
+func (v *validator) walk(prefix string, rv reflect.Value) {
+	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return
+	}
+
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name := jsonName(sf)
+		if name == "-" {
+			continue
+		}
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+
+		fv := rv.Field(i)
+		v.field(name, fv, sf.Tag.Get("validate"))
+
+		if fv.Kind() == reflect.Struct && fv.Type().NumMethod() == 0 {
+			v.walk(name, fv)
+		}
+	}
+}
+
+// rule is one entry of a `validate` tag; n is the argument of min and max.
+type rule struct {
+	name string
+	n    int
+}
+
+func parseRules(tag string) ([]rule, error) {
+	var rules []rule
+	for _, entry := range strings.Split(tag, ",") {
+		name, arg, hasArg := strings.Cut(entry, "=")
+		r := rule{name: name}
+		switch name {
+		case "required", "email", "uuid":
+			if hasArg {
+				return nil, fmt.Errorf("validate: rule %q takes no argument", entry)
+			}
+		case "min", "max":
+			n, err := strconv.Atoi(arg)
+			if err != nil {
+				return nil, fmt.Errorf("validate: bad argument in rule %q", entry)
+			}
+			r.n = n
+		default:
+			return nil, fmt.Errorf("validate: unknown rule %q", entry)
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+func (v *validator) field(name string, fv reflect.Value, tag string) {
+	if tag == "" {
+		return
+	}
+
+	rules, err := parseRules(tag)
+	if err != nil {
+		if v.tagErr == nil {
+			v.tagErr = fmt.Errorf("field %s: %w", name, err)
+		}
+		return
+	}
+
+	for _, r := range rules {
+		if r.name == "required" && fv.IsZero() {
+			v.add(name, "required", "is required")
+			return
+		}
+	}
+
+	if fv.Kind() != reflect.String || fv.Len() == 0 {
+		return
+	}
+	value := fv.String()
+
+	for _, r := range rules {
+		switch r.name {
+		case "email":
+			addr, err := mail.ParseAddress(value)
+			v.check(err == nil && addr.Address == value, name, "invalid_email", "must be a valid email address")
+		case "uuid":
+			_, err := uuid.Parse(value)
+			v.check(err == nil, name, "invalid_uuid", "must be a valid UUID")
+		case "min":
+			v.check(utf8.RuneCountInString(value) >= r.n, name, "too_short", fmt.Sprintf("must be at least %d characters", r.n))
+		case "max":
+			v.check(utf8.RuneCountInString(value) <= r.n, name, "too_long", fmt.Sprintf("must be at most %d characters", r.n))
+		}
+	}
+}
+
+func jsonName(sf reflect.StructField) string {
+	name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+	if name == "" {
+		return sf.Name
+	}
+	return name
+}
+
+// parseUUIDParam parses a path or query value, reporting a field error
+// under name when it is not a UUID.
+func parseUUIDParam(name, value string) (uuid.UUID, error) {
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, validationError(FieldError{
+			Field:   name,
+			Code:    "invalid_uuid",
+			Message: "must be a valid UUID",
+		})
+	}
+	return id, nil
+}
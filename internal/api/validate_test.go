@@ -0,0 +1,169 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func fieldCodes(t *testing.T, err error) map[string]string {
+	t.Helper()
+
+	if err == nil {
+		return nil
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != CodeValidationFailed {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+	codes := make(map[string]string)
+	for _, f := range apiErr.Fields {
+		codes[f.Field] = f.Code
+	}
+	return codes
+}
+
+func TestValidateStruct(t *testing.T) {
+	type nested struct {
+		ID string `json:"id" validate:"required,uuid"`
+	}
+	type request struct {
+		Email string `json:"email" validate:"required,email"`
+		Name  string `json:"name" validate:"min=2,max=5"`
+		Inner nested `json:"inner"`
+	}
+
+	tests := []struct {
+		name string
+		req  request
+		want map[string]string
+	}{
+		{
+			name: "valid",
+			req:  request{Email: "a@example.com", Name: "héllo", Inner: nested{ID: "6f1b0c3e-2d4a-4b8e-9a51-0c7d3e2f1a9b"}},
+		},
+		{
+			name: "missing",
+			req:  request{},
+			want: map[string]string{"email": "required", "inner.id": "required"},
+		},
+		{
+			name: "malformed",
+			req:  request{Email: "Alice <a@example.com>", Name: "x", Inner: nested{ID: "nope"}},
+			want: map[string]string{"email": "invalid_email", "name": "too_short", "inner.id": "invalid_uuid"},
+		},
+		{
+			name: "too long",
+			req:  request{Email: "a@example.com", Name: "héllo!", Inner: nested{ID: "6f1b0c3e-2d4a-4b8e-9a51-0c7d3e2f1a9b"}},
+			want: map[string]string{"name": "too_long"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fieldCodes(t, validateStruct(tt.req))
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+			for field, code := range tt.want {
+				if got[field] != code {
+					t.Fatalf("expected %v, got %v", tt.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeJSON(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ts *testServer) {
+		alice := ts.createUser("alice@example.com")
+
+		post := func(contentType, body string) *http.Response {
+			t.Helper()
+			req, err := http.NewRequest("POST", ts.server.URL+"/api/chirps", strings.NewReader(body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Authorization", alice.bearer())
+			if contentType != "" {
+				req.Header.Set("Content-Type", contentType)
+			}
+			resp, err := ts.server.Client().Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { resp.Body.Close() })
+			return resp
+		}
+
+		expectProblem(t, post("", `{"body":"hi"}`), http.StatusUnsupportedMediaType, CodeUnsupportedMediaType)
+		expectProblem(t, post("text/plain", `{"body":"hi"}`), http.StatusUnsupportedMediaType, CodeUnsupportedMediaType)
+		expectStatus(t, post("application/json; charset=utf-8", `{"body":"hi"}`), http.StatusCreated)
+
+		expectProblem(t, post("application/json", `{"body":"hi"`), http.StatusBadRequest, CodeInvalidRequest)
+		expectProblem(t, post("application/json", `{"body":"hi"} {}`), http.StatusBadRequest, CodeInvalidRequest)
+		expectProblem(t, post("application/json", ``), http.StatusBadRequest, CodeInvalidRequest)
+
+		problem := expectProblem(t, post("application/json", `{"body":"hi","extra":1}`), http.StatusBadRequest, CodeValidationFailed)
+		if len(problem.Errors) != 1 || problem.Errors[0].Field != "extra" {
+			t.Fatalf("unexpected field errors %+v", problem.Errors)
+		}
+
+		problem = expectProblem(t, post("application/json", `{"body":42}`), http.StatusBadRequest, CodeValidationFailed)
+		if len(problem.Errors) != 1 || problem.Errors[0].Field != "body" {
+			t.Fatalf("unexpected field errors %+v", problem.Errors)
+		}
+
+		var big bytes.Buffer
+		big.WriteString(`{"body":"`)
+		big.WriteString(strings.Repeat("a", maxJSONBodyBytes))
+		big.WriteString(`"}`)
+		expectProblem(t, post("application/json", big.String()), http.StatusRequestEntityTooLarge, CodePayloadTooLarge)
+	})
+}
+
+func TestCreateChirp_CountsCharacters(t *testing.T) {
+	forEachStore(t, func(t *testing.T, ts *testServer) {
+		alice := ts.createUser("alice@example.com")
+
+		// 140 two-byte characters is 280 bytes but still a valid chirp.
+		ts.createChirp(alice, strings.Repeat("é", 140))
+
+		resp := ts.do("POST", "/api/chirps", alice.bearer(), map[string]string{"body": strings.Repeat("é", 141)})
+		problem := expectProblem(t, resp, http.StatusBadRequest, CodeValidationFailed)
+		if problem.Errors[0].Field != "body" || problem.Errors[0].Code != "too_long" {
+			t.Fatalf("unexpected field errors %+v", problem.Errors)
+		}
+
+		resp = ts.do("POST", "/api/chirps", alice.bearer(), map[string]string{"body": ""})
+		expectProblem(t, resp, http.StatusBadRequest, CodeValidationFailed)
+
+		resp = ts.do("GET", "/api/chirps/not-a-uuid", "", nil)
+		expectProblem(t, resp, http.StatusBadRequest, CodeValidationFailed)
+	})
+}
+
+func TestValidateStruct_BadTag(t *testing.T) {
+	tests := []struct {
+		name string
+		req  any
+	}{
+		{"unknown rule", struct {
+			Name string `json:"name" validate:"bogus"`
+		}{Name: "x"}},
+		{"bad argument", struct {
+			Name string `json:"name" validate:"min=x"`
+		}{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var apiErr *APIError
+			if err := validateStruct(tt.req); !errors.As(err, &apiErr) || apiErr.Status != http.StatusInternalServerError || apiErr.Code != CodeInternal {
+				t.Fatalf("expected an internal error, got %v", err)
+			}
+		})
+	}
+}
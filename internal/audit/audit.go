@@ -0,0 +1,147 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ihyaulhaq/go-server/internal/database"
+)
+
+const (
+	ActionLogin         = "user.login"
+	ActionLoginFailed   = "user.login_failed"
+	ActionUserUpdate    = "user.update"
+	ActionUserUpgrade   = "user.upgrade"
+	ActionUserSuspend   = "user.suspend"
+	ActionUserUnsuspend = "user.unsuspend"
+	ActionUserBan       = "user.ban"
+	ActionUserUnban     = "user.unban"
+	ActionUserDelete    = "user.delete_scheduled"
+	ActionTokenRevoke   = "token.revoke"
+	ActionAdminReset    = "admin.reset"
+	ActionAdminSeed     = "admin.seed"
+)
+
+// Event is a single security-relevant action. ActorID is uuid.Nil when the
+// caller is anonymous or an external system such as a webhook.
+type Event struct {
+	ActorID   uuid.UUID
+	Action    string
+	Target    string
+	IP        string
+	UserAgent string
+	CreatedAt time.Time
+}
+
+type Auditor interface {
+	Record(ctx context.Context, event Event)
+}
+
+// Writer persists a single event.
+type Writer interface {
+	WriteEvent(ctx context.Context, event Event) error
+}
+
+type DBWriter struct {
+	DB database.Store
+}
+
+func (w DBWriter) WriteEvent(ctx context.Context, event Event) error {
+	return w.DB.CreateAuditEvent(ctx, database.CreateAuditEventParams{
+		CreatedAt: event.CreatedAt,
+		ActorID:   uuid.NullUUID{UUID: event.ActorID, Valid: event.ActorID != uuid.Nil},
+		Action:    event.Action,
+		Target:    event.Target,
+		Ip:        event.IP,
+		UserAgent: event.UserAgent,
+	})
+}
+
+// AsyncAuditor queues events in a bounded buffer and writes them from a
+// single background goroutine, so Record never blocks the request path.
+// Events are dropped and counted when the buffer is full.
+type AsyncAuditor struct {
+	writer       Writer
+	writeTimeout time.Duration
+
+	mu     sync.RWMutex
+	closed bool
+	queue  chan Event
+	done   chan struct{}
+
+	dropped atomic.Int64
+}
+
+func NewAsyncAuditor(writer Writer, size int) *AsyncAuditor {
+	a := &AsyncAuditor{
+		writer:       writer,
+		writeTimeout: 5 * time.Second,
+		queue:        make(chan Event, size),
+		done:         make(chan struct{}),
+	}
+	go a.run()
+	return a
+}
+
+func (a *AsyncAuditor) Record(ctx context.Context, event Event) {
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now().UTC()
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.closed {
+		a.dropped.Add(1)
+		return
+	}
+
+	select {
+	case a.queue <- event:
+	default:
+		a.dropped.Add(1)
+		slog.Warn("audit queue full, dropping event", "action", event.Action)
+	}
+}
+
+// Dropped reports how many events were discarded because the queue was full
+// or the auditor was closed.
+func (a *AsyncAuditor) Dropped() int64 {
+	return a.dropped.Load()
+}
+
+// Close stops accepting events and waits for queued ones to be written.
+func (a *AsyncAuditor) Close(ctx context.Context) error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return errors.New("audit: auditor already closed")
+	}
+	a.closed = true
+	close(a.queue)
+	a.mu.Unlock()
+
+	select {
+	case <-a.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (a *AsyncAuditor) run() {
+	defer close(a.done)
+
+	for event := range a.queue {
+		ctx, cancel := context.WithTimeout(context.Background(), a.writeTimeout)
+		if err := a.writer.WriteEvent(ctx, event); err != nil {
+			slog.Error("error writing audit event", "action", event.Action, "error", err)
+		}
+		cancel()
+	}
+}
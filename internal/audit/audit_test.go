@@ -0,0 +1,89 @@
+package audit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type recordingWriter struct {
+	mu     sync.Mutex
+	events []Event
+	block  chan struct{}
+}
+
+func (w *recordingWriter) WriteEvent(ctx context.Context, event Event) error {
+	if w.block != nil {
+		<-w.block
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.events = append(w.events, event)
+	return nil
+}
+
+func TestAsyncAuditor_WritesQueuedEventsOnClose(t *testing.T) {
+	writer := &recordingWriter{}
+	auditor := NewAsyncAuditor(writer, 10)
+
+	actor := uuid.New()
+	auditor.Record(context.Background(), Event{ActorID: actor, Action: ActionLogin})
+	auditor.Record(context.Background(), Event{Action: ActionAdminReset})
+
+	if err := auditor.Close(context.Background()); err != nil {
+		t.Fatalf("close error: %v", err)
+	}
+
+	if len(writer.events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(writer.events))
+	}
+
+	if writer.events[0].ActorID != actor {
+		t.Fatalf("expected actor %s, got %s", actor, writer.events[0].ActorID)
+	}
+
+	if writer.events[0].CreatedAt.IsZero() {
+		t.Fatal("expected CreatedAt to be set")
+	}
+}
+
+func TestAsyncAuditor_DropsWhenFull(t *testing.T) {
+	writer := &recordingWriter{block: make(chan struct{})}
+	auditor := NewAsyncAuditor(writer, 1)
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		auditor.Record(context.Background(), Event{Action: ActionLogin})
+	}
+
+	if time.Since(start) > time.Second {
+		t.Fatal("Record should not block when the queue is full")
+	}
+
+	if auditor.Dropped() == 0 {
+		t.Fatal("expected some events to be dropped")
+	}
+
+	close(writer.block)
+	if err := auditor.Close(context.Background()); err != nil {
+		t.Fatalf("close error: %v", err)
+	}
+}
+
+func TestAsyncAuditor_RecordAfterClose(t *testing.T) {
+	writer := &recordingWriter{}
+	auditor := NewAsyncAuditor(writer, 1)
+
+	if err := auditor.Close(context.Background()); err != nil {
+		t.Fatalf("close error: %v", err)
+	}
+
+	auditor.Record(context.Background(), Event{Action: ActionLogin})
+
+	if auditor.Dropped() != 1 {
+		t.Fatalf("expected 1 dropped event, got %d", auditor.Dropped())
+	}
+}
@@ -0,0 +1,146 @@
+// Package certs serves a TLS certificate from files on disk and picks up
+// renewed certificates without restarting the server.
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// Reloader holds the certificate loaded from a cert/key file pair. Its
+// GetCertificate is consulted on every handshake, so a reload applies to
+// new connections while established ones carry on with the old
+// certificate.
+type Reloader struct {
+	certFile string
+	keyFile  string
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	version fileVersion
+}
+
+// fileVersion identifies the state of the cert and key files on disk.
+type fileVersion struct {
+	certMod, keyMod   time.Time
+	certSize, keySize int64
+}
+
+// NewReloader loads the certificate, failing if the files are missing or
+// don't form a valid pair.
+func NewReloader(certFile, keyFile string) (*Reloader, error) {
+	r := &Reloader{certFile: certFile, keyFile: keyFile}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate.
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// Reload reads the files again. If they don't hold a valid pair, for
+// example because a renewal is half written, the current certificate is
+// kept and the error returned.
+func (r *Reloader) Reload() error {
+	version, err := r.stat()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("loading certificate: %w", err)
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.version = version
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *Reloader) stat() (fileVersion, error) {
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return fileVersion{}, fmt.Errorf("reading certificate: %w", err)
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return fileVersion{}, fmt.Errorf("reading key: %w", err)
+	}
+	return fileVersion{
+		certMod:  certInfo.ModTime(),
+		keyMod:   keyInfo.ModTime(),
+		certSize: certInfo.Size(),
+		keySize:  keyInfo.Size(),
+	}, nil
+}
+
+// changed reports whether the files differ from the loaded ones.
+func (r *Reloader) changed() (bool, error) {
+	version, err := r.stat()
+	if err != nil {
+		return false, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return version != r.version, nil
+}
+
+// Watch polls the files every interval and reloads them when they change,
+// until ctx is cancelled. Failed reloads are logged and retried on the next
+// tick.
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if changed, err := r.changed(); err == nil && !changed {
+			continue
+		}
+		if err := r.Reload(); err != nil {
+			slog.Error("error reloading TLS certificate", "error", err, "cert_file", r.certFile)
+			continue
+		}
+		slog.Info("reloaded TLS certificate", "cert_file", r.certFile, "not_after", r.NotAfter())
+	}
+}
+
+// NotAfter returns the expiry of the current certificate.
+func (r *Reloader) NotAfter() time.Time {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert.Leaf.NotAfter
+}
+
+// LoadCertPool reads PEM certificates from file, for verifying clients.
+func LoadCertPool(file string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("reading CA file: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no PEM certificates in %s", file)
+	}
+	return pool, nil
+}
@@ -0,0 +1,195 @@
+package certs
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeSelfSigned writes a self-signed localhost certificate with the
+// given serial number and its key to dir, returning their paths.
+func writeSelfSigned(t *testing.T, dir string, serial int64) (certFile, keyFile string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile = filepath.Join(dir, "tls.crt")
+	keyFile = filepath.Join(dir, "tls.key")
+	writePEM(t, certFile, "CERTIFICATE", der)
+	writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+	return certFile, keyFile
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func servedSerial(t *testing.T, r *Reloader) int64 {
+	t.Helper()
+	cert, err := r.GetCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert.Leaf.SerialNumber.Int64()
+}
+
+func TestReloader_ReloadKeepsConnections(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeSelfSigned(t, dir, 1)
+
+	r, err := NewReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	server.TLS = &tls.Config{GetCertificate: r.GetCertificate}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	newClient := func() *http.Client {
+		return &http.Client{Transport: &http.Transport{
+			// httptest installs its own certificate, which Go serves to
+			// clients that don't send SNI.
+			TLSClientConfig: &tls.Config{ServerName: "localhost", InsecureSkipVerify: true},
+		}}
+	}
+	peerSerial := func(client *http.Client) int64 {
+		t.Helper()
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.TLS.PeerCertificates[0].SerialNumber.Int64()
+	}
+
+	existing := newClient()
+	if got := peerSerial(existing); got != 1 {
+		t.Fatalf("expected serial 1, got %d", got)
+	}
+
+	writeSelfSigned(t, dir, 2)
+	if err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := peerSerial(newClient()); got != 2 {
+		t.Fatalf("expected new connections to get serial 2, got %d", got)
+	}
+	if got := peerSerial(existing); got != 1 {
+		t.Fatalf("expected the kept-alive connection to survive the reload, got serial %d", got)
+	}
+}
+
+func TestReloader_KeepsCertificateOnBadFiles(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeSelfSigned(t, dir, 1)
+
+	r, err := NewReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(keyFile, []byte("half written"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Reload(); err == nil {
+		t.Fatal("expected reload of a broken key to fail")
+	}
+	if got := servedSerial(t, r); got != 1 {
+		t.Fatalf("expected serial 1 to be kept, got %d", got)
+	}
+
+	if _, err := NewReloader(filepath.Join(dir, "missing.crt"), keyFile); err == nil {
+		t.Fatal("expected missing files to fail at startup")
+	}
+}
+
+func TestReloader_Watch(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeSelfSigned(t, dir, 1)
+
+	r, err := NewReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		r.Watch(ctx, 10*time.Millisecond)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	writeSelfSigned(t, dir, 2)
+	// Make the change visible even on filesystems with coarse mtimes.
+	future := time.Now().Add(time.Minute)
+	for _, f := range []string{certFile, keyFile} {
+		if err := os.Chtimes(f, future, future); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for servedSerial(t, r) != 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected Watch to pick up the new certificate")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestLoadCertPool(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeSelfSigned(t, dir, 1)
+
+	if _, err := LoadCertPool(certFile); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := LoadCertPool(keyFile); err == nil {
+		t.Fatal("expected a file without certificates to be rejected")
+	}
+}
@@ -0,0 +1,506 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ihyaulhaq/go-server/internal/database"
+	"github.com/ihyaulhaq/go-server/internal/ratelimit"
+)
+
+const (
+	RateLimitStoreMemory   = "memory"
+	RateLimitStoreDatabase = "database"
+)
+
+// usageOutput is where flag usage is printed; tests replace it.
+var usageOutput io.Writer = os.Stderr
+
+type Config struct {
+	Port     string
+	FileRoot string
+	DBDriver string
+	DBURL    string
+	Platform string
+
+	Secret   string
+	PolkaKey string
+	AdminKey string
+
+	SeedFile string
+
+	ChirpRestoreWindow   time.Duration
+	ChirpRetention       time.Duration
+	AccountDeletionDelay time.Duration
+	IdempotencyTTL       time.Duration
+
+	ReadHeaderTimeout  time.Duration
+	ReadTimeout        time.Duration
+	WriteTimeout       time.Duration
+	IdleTimeout        time.Duration
+	MaxHeaderBytes     int
+	HealthCheckTimeout time.Duration
+
+	// On shutdown, readiness fails for ShutdownDrainDelay while the server
+	// keeps serving, so load balancers stop routing to it before the
+	// listeners close. In-flight requests then get ShutdownTimeout to
+	// finish.
+	ShutdownDrainDelay time.Duration
+	ShutdownTimeout    time.Duration
+
+	TracesExporter string
+	ServiceName    string
+
+	// RateLimits is a ratelimit.ParsePolicies spec; RateLimitStore is
+	// "memory" for per-replica limits or "database" to share them.
+	RateLimits     string
+	RateLimitStore string
+
+	// The CORS lists are comma-separated; CORS is off when no origins are
+	// allowed.
+	CORSAllowedOrigins   string
+	CORSAllowedMethods   string
+	CORSAllowedHeaders   string
+	CORSAllowCredentials bool
+	CORSMaxAge           time.Duration
+
+	// ContentSecurityPolicy applies to the API; AppContentSecurityPolicy
+	// replaces it for the web UI under /app/.
+	ContentSecurityPolicy    string
+	AppContentSecurityPolicy string
+	ReferrerPolicy           string
+	HSTSMaxAge               time.Duration
+
+	// TLS is served when both TLSCertFile and TLSKeyFile are set; the files
+	// are reloaded when they change. TLSClientCAFile makes /admin/ routes
+	// require a client certificate it signed, and HTTPRedirectPort, when
+	// set, redirects plain HTTP on that port to HTTPS.
+	TLSCertFile      string
+	TLSKeyFile       string
+	TLSClientCAFile  string
+	HTTPRedirectPort string
+
+	// AutoMigrate applies pending migrations before serving.
+	AutoMigrate bool
+
+	// PrintConfig asks the caller to print the redacted config and exit.
+	PrintConfig bool
+}
+
+func Default() Config {
+	return Config{
+		Port:     "8080",
+		DBDriver: database.DriverPostgres,
+
+		ChirpRestoreWindow:   24 * time.Hour,
+		ChirpRetention:       30 * 24 * time.Hour,
+		AccountDeletionDelay: 14 * 24 * time.Hour,
+		IdempotencyTTL:       24 * time.Hour,
+
+		ReadHeaderTimeout:  5 * time.Second,
+		ReadTimeout:        15 * time.Second,
+		WriteTimeout:       30 * time.Second,
+		IdleTimeout:        120 * time.Second,
+		MaxHeaderBytes:     1 << 20,
+		ShutdownDrainDelay: 5 * time.Second,
+		ShutdownTimeout:    30 * time.Second,
+		HealthCheckTimeout: 2 * time.Second,
+
+		TracesExporter: "none",
+		ServiceName:    "chirpy",
+
+		RateLimits:     "POST /api/chirps=30/1m,POST /api/login=10/1m,POST /api/users=10/1h",
+		RateLimitStore: RateLimitStoreMemory,
+
+		CORSAllowedMethods: "GET,POST,PUT,DELETE",
+		CORSAllowedHeaders: "Authorization,Content-Type,Idempotency-Key,If-Match,If-None-Match",
+		CORSMaxAge:         10 * time.Minute,
+
+		ContentSecurityPolicy:    "default-src 'none'; frame-ancestors 'none'",
+		AppContentSecurityPolicy: "default-src 'self'; img-src 'self' data:; base-uri 'self'; form-action 'self'; frame-ancestors 'none'",
+		ReferrerPolicy:           "no-referrer",
+		HSTSMaxAge:               180 * 24 * time.Hour,
+	}
+}
+
+// setting describes one configuration key. name is used both as the
+// command-line flag and as the key in the config file.
+type setting struct {
+	name   string
+	env    string
+	usage  string
+	secret bool
+	bind   func(fs *flag.FlagSet, cfg *Config, name, usage string)
+}
+
+func str(field func(*Config) *string) func(*flag.FlagSet, *Config, string, string) {
+	return func(fs *flag.FlagSet, cfg *Config, name, usage string) {
+		p := field(cfg)
+		fs.StringVar(p, name, *p, usage)
+	}
+}
+
+func dur(field func(*Config) *time.Duration) func(*flag.FlagSet, *Config, string, string) {
+	return func(fs *flag.FlagSet, cfg *Config, name, usage string) {
+		p := field(cfg)
+		fs.DurationVar(p, name, *p, usage)
+	}
+}
+
+func boolean(field func(*Config) *bool) func(*flag.FlagSet, *Config, string, string) {
+	return func(fs *flag.FlagSet, cfg *Config, name, usage string) {
+		p := field(cfg)
+		fs.BoolVar(p, name, *p, usage)
+	}
+}
+
+func integer(field func(*Config) *int) func(*flag.FlagSet, *Config, string, string) {
+	return func(fs *flag.FlagSet, cfg *Config, name, usage string) {
+		p := field(cfg)
+		fs.IntVar(p, name, *p, usage)
+	}
+}
+
+var settings = []setting{
+	{"port", "PORT", "port to listen on", false, str(func(c *Config) *string { return &c.Port })},
+	{"file-root", "FILE_ROOT", "serve /app/ from this directory on disk, re-read on every request, instead of the embedded files", false, str(func(c *Config) *string { return &c.FileRoot })},
+	{"db-driver", "DB_DRIVER", "database backend: postgres or sqlite", false, str(func(c *Config) *string { return &c.DBDriver })},
+	{"db-url", "DB_URL", "Postgres connection URL, or SQLite database file path", true, str(func(c *Config) *string { return &c.DBURL })},
+	{"platform", "PLATFORM", `deployment platform; "dev" enables admin reset`, false, str(func(c *Config) *string { return &c.Platform })},
+	{"secret", "SECRET", "JWT signing secret", true, str(func(c *Config) *string { return &c.Secret })},
+	{"polka-key", "POLKA_KEY", "API key for Polka webhooks", true, str(func(c *Config) *string { return &c.PolkaKey })},
+	{"admin-key", "ADMIN_KEY", "API key for /admin moderation endpoints", true, str(func(c *Config) *string { return &c.AdminKey })},
+	{"seed-file", "SEED_FILE", "fixture loaded by POST /admin/seed; defaults to the built-in one", false, str(func(c *Config) *string { return &c.SeedFile })},
+	{"chirp-restore-window", "CHIRP_RESTORE_WINDOW", "how long a deleted chirp can be restored", false, dur(func(c *Config) *time.Duration { return &c.ChirpRestoreWindow })},
+	{"chirp-retention", "CHIRP_RETENTION", "how long deleted chirps are kept before purging", false, dur(func(c *Config) *time.Duration { return &c.ChirpRetention })},
+	{"account-deletion-delay", "ACCOUNT_DELETION_DELAY", "cooling-off period before a deleted account is removed", false, dur(func(c *Config) *time.Duration { return &c.AccountDeletionDelay })},
+	{"idempotency-ttl", "IDEMPOTENCY_TTL", "how long responses to requests with an Idempotency-Key are replayed", false, dur(func(c *Config) *time.Duration { return &c.IdempotencyTTL })},
+	{"read-header-timeout", "READ_HEADER_TIMEOUT", "HTTP read header timeout", false, dur(func(c *Config) *time.Duration { return &c.ReadHeaderTimeout })},
+	{"read-timeout", "READ_TIMEOUT", "HTTP read timeout", false, dur(func(c *Config) *time.Duration { return &c.ReadTimeout })},
+	{"write-timeout", "WRITE_TIMEOUT", "HTTP write timeout", false, dur(func(c *Config) *time.Duration { return &c.WriteTimeout })},
+	{"idle-timeout", "IDLE_TIMEOUT", "HTTP keep-alive idle timeout", false, dur(func(c *Config) *time.Duration { return &c.IdleTimeout })},
+	{"max-header-bytes", "MAX_HEADER_BYTES", "maximum size of request headers", false, integer(func(c *Config) *int { return &c.MaxHeaderBytes })},
+	{"shutdown-drain-delay", "SHUTDOWN_DRAIN_DELAY", "how long readiness fails before the listeners close on shutdown, so load balancers can react; 0 disables", false, dur(func(c *Config) *time.Duration { return &c.ShutdownDrainDelay })},
+	{"shutdown-timeout", "SHUTDOWN_TIMEOUT", "how long to drain in-flight requests on shutdown", false, dur(func(c *Config) *time.Duration { return &c.ShutdownTimeout })},
+	{"health-check-timeout", "HEALTH_CHECK_TIMEOUT", "timeout for each readiness check", false, dur(func(c *Config) *time.Duration { return &c.HealthCheckTimeout })},
+	{"traces-exporter", "OTEL_TRACES_EXPORTER", "trace exporter: none, stdout or otlp", false, str(func(c *Config) *string { return &c.TracesExporter })},
+	{"service-name", "OTEL_SERVICE_NAME", "service name reported in traces", false, str(func(c *Config) *string { return &c.ServiceName })},
+	{"rate-limits", "RATE_LIMITS", `per-route limits, e.g. "POST /api/login=10/1m,POST /api/chirps=30/1m"`, false, str(func(c *Config) *string { return &c.RateLimits })},
+	{"rate-limit-store", "RATE_LIMIT_STORE", "where rate limit buckets live: memory or database", false, str(func(c *Config) *string { return &c.RateLimitStore })},
+	{"cors-allowed-origins", "CORS_ALLOWED_ORIGINS", `comma-separated origins allowed to call the API cross-origin, or "*"; empty disables CORS`, false, str(func(c *Config) *string { return &c.CORSAllowedOrigins })},
+	{"cors-allowed-methods", "CORS_ALLOWED_METHODS", "comma-separated methods allowed in cross-origin requests", false, str(func(c *Config) *string { return &c.CORSAllowedMethods })},
+	{"cors-allowed-headers", "CORS_ALLOWED_HEADERS", "comma-separated request headers allowed in cross-origin requests", false, str(func(c *Config) *string { return &c.CORSAllowedHeaders })},
+	{"cors-allow-credentials", "CORS_ALLOW_CREDENTIALS", "let cross-origin requests send cookies and HTTP auth", false, boolean(func(c *Config) *bool { return &c.CORSAllowCredentials })},
+	{"cors-max-age", "CORS_MAX_AGE", "how long browsers may cache a CORS preflight", false, dur(func(c *Config) *time.Duration { return &c.CORSMaxAge })},
+	{"content-security-policy", "CONTENT_SECURITY_POLICY", "Content-Security-Policy for API responses", false, str(func(c *Config) *string { return &c.ContentSecurityPolicy })},
+	{"app-content-security-policy", "APP_CONTENT_SECURITY_POLICY", "Content-Security-Policy for the web UI under /app/", false, str(func(c *Config) *string { return &c.AppContentSecurityPolicy })},
+	{"referrer-policy", "REFERRER_POLICY", "Referrer-Policy sent with every response", false, str(func(c *Config) *string { return &c.ReferrerPolicy })},
+	{"hsts-max-age", "HSTS_MAX_AGE", "Strict-Transport-Security max-age for HTTPS responses; 0 disables", false, dur(func(c *Config) *time.Duration { return &c.HSTSMaxAge })},
+	{"tls-cert-file", "TLS_CERT_FILE", "PEM certificate chain to serve HTTPS with; reloaded on change or SIGHUP", false, str(func(c *Config) *string { return &c.TLSCertFile })},
+	{"tls-key-file", "TLS_KEY_FILE", "PEM private key for tls-cert-file", false, str(func(c *Config) *string { return &c.TLSKeyFile })},
+	{"tls-client-ca-file", "TLS_CLIENT_CA_FILE", "PEM CA certificates; /admin/ routes then require a client certificate signed by one", false, str(func(c *Config) *string { return &c.TLSClientCAFile })},
+	{"http-redirect-port", "HTTP_REDIRECT_PORT", "port on which to redirect plain HTTP to HTTPS; empty disables", false, str(func(c *Config) *string { return &c.HTTPRedirectPort })},
+	{"auto-migrate", "AUTO_MIGRATE", "apply pending migrations before serving", false, boolean(func(c *Config) *bool { return &c.AutoMigrate })},
+}
+
+// Load builds the configuration from, in increasing precedence: defaults,
+// an optional JSON config file, environment variables and command-line
+// flags, and validates the result. The config file is named by --config or
+// CONFIG_FILE.
+func Load(args []string, getenv func(string) string) (*Config, error) {
+	cfg, err := LoadUnvalidated(args, getenv)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// LoadUnvalidated is Load without validation, for commands such as migrate
+// that only need part of the configuration.
+func LoadUnvalidated(args []string, getenv func(string) string) (*Config, error) {
+	cfg := Default()
+
+	fs := flag.NewFlagSet("chirpy", flag.ContinueOnError)
+	// Parse writes usage here for --help and for flag errors.
+	fs.SetOutput(usageOutput)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: chirpy [flags]\n\nFlags can also be set by the environment variable shown or in the --config file.")
+		fs.PrintDefaults()
+	}
+	configPath := fs.String("config", getenv("CONFIG_FILE"), "path to a JSON config file")
+	fs.BoolVar(&cfg.PrintConfig, "print-config", false, "print the effective config with secrets redacted and exit")
+	for _, s := range settings {
+		s.bind(fs, &cfg, s.name, s.usage+" ($"+s.env+")")
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return nil, fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+
+	// Flags were applied during Parse; remember them so they can be
+	// re-applied on top of the file and environment layers.
+	explicit := map[string]string{}
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = f.Value.String()
+	})
+
+	printConfig := cfg.PrintConfig
+	cfg = Default()
+	cfg.PrintConfig = printConfig
+
+	if *configPath != "" {
+		if err := loadFile(fs, *configPath); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, s := range settings {
+		if val := getenv(s.env); val != "" {
+			if err := fs.Set(s.name, val); err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", s.env, err)
+			}
+		}
+	}
+
+	for name, val := range explicit {
+		if err := fs.Set(name, val); err != nil {
+			return nil, fmt.Errorf("invalid --%s: %w", name, err)
+		}
+	}
+
+	return &cfg, nil
+}
+
+func loadFile(fs *flag.FlagSet, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+
+	values := map[string]interface{}{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+
+	for name, raw := range values {
+		if fs.Lookup(name) == nil || name == "config" || name == "print-config" {
+			return fmt.Errorf("config file %s: unknown key %q", path, name)
+		}
+
+		var val string
+		switch v := raw.(type) {
+		case string:
+			val = v
+		case float64:
+			val = strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			val = strconv.FormatBool(v)
+		default:
+			return fmt.Errorf("config file %s: %q must be a string, number or boolean", path, name)
+		}
+
+		if err := fs.Set(name, val); err != nil {
+			return fmt.Errorf("config file %s: invalid %q: %w", path, name, err)
+		}
+	}
+
+	return nil
+}
+
+func (cfg *Config) Validate() error {
+	var errs []error
+
+	if cfg.Secret == "" {
+		errs = append(errs, errors.New("secret must not be empty"))
+	}
+
+	if err := cfg.ValidateDatabase(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if !validPort(cfg.Port) {
+		errs = append(errs, fmt.Errorf("port must be between 1 and 65535, got %q", cfg.Port))
+	}
+
+	for name, d := range map[string]time.Duration{
+		"chirp-restore-window":   cfg.ChirpRestoreWindow,
+		"chirp-retention":        cfg.ChirpRetention,
+		"account-deletion-delay": cfg.AccountDeletionDelay,
+		"idempotency-ttl":        cfg.IdempotencyTTL,
+		"read-header-timeout":    cfg.ReadHeaderTimeout,
+		"read-timeout":           cfg.ReadTimeout,
+		"write-timeout":          cfg.WriteTimeout,
+		"idle-timeout":           cfg.IdleTimeout,
+		"shutdown-timeout":       cfg.ShutdownTimeout,
+		"health-check-timeout":   cfg.HealthCheckTimeout,
+	} {
+		if d <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive", name))
+		}
+	}
+
+	if cfg.ChirpRetention < cfg.ChirpRestoreWindow {
+		errs = append(errs, errors.New("chirp-retention must not be shorter than chirp-restore-window"))
+	}
+
+	if cfg.MaxHeaderBytes <= 0 {
+		errs = append(errs, errors.New("max-header-bytes must be positive"))
+	}
+
+	switch cfg.TracesExporter {
+	case "none", "stdout", "otlp":
+	default:
+		errs = append(errs, fmt.Errorf("traces-exporter must be none, stdout or otlp, got %q", cfg.TracesExporter))
+	}
+
+	if _, err := ratelimit.ParsePolicies(cfg.RateLimits); err != nil {
+		errs = append(errs, fmt.Errorf("rate-limits: %w", err))
+	}
+
+	switch cfg.RateLimitStore {
+	case RateLimitStoreMemory, RateLimitStoreDatabase:
+	default:
+		errs = append(errs, fmt.Errorf("rate-limit-store must be memory or database, got %q", cfg.RateLimitStore))
+	}
+
+	if cfg.ShutdownDrainDelay < 0 {
+		errs = append(errs, errors.New("shutdown-drain-delay must not be negative"))
+	}
+
+	for _, origin := range SplitList(cfg.CORSAllowedOrigins) {
+		if origin == "*" {
+			if cfg.CORSAllowCredentials {
+				errs = append(errs, errors.New(`cors-allowed-origins must not be "*" when cors-allow-credentials is set`))
+			}
+			continue
+		}
+		if u, err := url.Parse(origin); err != nil || u.Scheme == "" || u.Host == "" || u.Path != "" {
+			errs = append(errs, fmt.Errorf("cors-allowed-origins: %q is not an origin like https://example.com", origin))
+		}
+	}
+
+	if cfg.CORSMaxAge < 0 {
+		errs = append(errs, errors.New("cors-max-age must not be negative"))
+	}
+	if cfg.HSTSMaxAge < 0 {
+		errs = append(errs, errors.New("hsts-max-age must not be negative"))
+	}
+
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		errs = append(errs, errors.New("tls-cert-file and tls-key-file must be set together"))
+	}
+	if !cfg.TLSEnabled() {
+		if cfg.TLSClientCAFile != "" {
+			errs = append(errs, errors.New("tls-client-ca-file requires tls-cert-file and tls-key-file"))
+		}
+		if cfg.HTTPRedirectPort != "" {
+			errs = append(errs, errors.New("http-redirect-port requires tls-cert-file and tls-key-file"))
+		}
+	}
+	if cfg.HTTPRedirectPort != "" {
+		if !validPort(cfg.HTTPRedirectPort) {
+			errs = append(errs, fmt.Errorf("http-redirect-port must be between 1 and 65535, got %q", cfg.HTTPRedirectPort))
+		} else if cfg.HTTPRedirectPort == cfg.Port {
+			errs = append(errs, errors.New("http-redirect-port must differ from port"))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// TLSEnabled reports whether the server should serve HTTPS.
+func (cfg *Config) TLSEnabled() bool {
+	return cfg.TLSCertFile != "" && cfg.TLSKeyFile != ""
+}
+
+func validPort(s string) bool {
+	port, err := strconv.Atoi(s)
+	return err == nil && port >= 1 && port <= 65535
+}
+
+// SplitList splits a comma-separated setting, dropping empty entries.
+func SplitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// ValidateDatabase checks only the settings needed to connect to the
+// database.
+func (cfg *Config) ValidateDatabase() error {
+	if cfg.DBURL == "" {
+		return errors.New("db-url must not be empty")
+	}
+
+	switch cfg.DBDriver {
+	case database.DriverPostgres:
+	case database.DriverSQLite:
+		return nil
+	default:
+		return fmt.Errorf("db-driver must be postgres or sqlite, got %q", cfg.DBDriver)
+	}
+
+	u, err := url.Parse(cfg.DBURL)
+	if err != nil {
+		return errors.New("db-url is not a valid URL")
+	}
+
+	if u.Scheme != "postgres" && u.Scheme != "postgresql" {
+		return fmt.Errorf("db-url scheme must be postgres or postgresql, got %q", u.Scheme)
+	}
+
+	if u.Host == "" {
+		return errors.New("db-url must include a host")
+	}
+
+	return nil
+}
+
+// Redacted returns the effective settings keyed by flag name, in the same
+// shape as the config file, with secrets masked.
+func (cfg *Config) Redacted() map[string]string {
+	c := *cfg
+	fs := flag.NewFlagSet("redacted", flag.ContinueOnError)
+	for _, s := range settings {
+		s.bind(fs, &c, s.name, s.usage)
+	}
+
+	out := make(map[string]string, len(settings))
+	for _, s := range settings {
+		val := fs.Lookup(s.name).Value.String()
+		switch {
+		case !s.secret || val == "":
+		case s.name == "db-url":
+			if u, err := url.Parse(val); err == nil {
+				val = u.Redacted()
+			} else {
+				val = "REDACTED"
+			}
+		default:
+			val = "REDACTED"
+		}
+		out[s.name] = val
+	}
+	return out
+}
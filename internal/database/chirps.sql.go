@@ -7,6 +7,8 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -25,7 +27,7 @@ INSERT INTO chirps (
   $1,
   $2
 )
-RETURNING id, created_at, updated_at, body, user_id
+RETURNING id, created_at, updated_at, body, user_id, deleted_at
 `
 
 type CreateChirpsParams struct {
@@ -42,23 +44,28 @@ func (q *Queries) CreateChirps(ctx context.Context, arg CreateChirpsParams) (Chi
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
 const deleteChirp = `-- name: DeleteChirp :execrows
-DELETE FROM chirps
+UPDATE chirps
+SET deleted_at = now()
 WHERE id = $1
-AND user_id = $2
+  AND user_id = $2
+  AND deleted_at IS NULL
+  AND ($3::timestamp IS NULL OR updated_at = $3)
 `
 
 type DeleteChirpParams struct {
-	ID     uuid.UUID
-	UserID uuid.UUID
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	UpdatedAt sql.NullTime
 }
 
 func (q *Queries) DeleteChirp(ctx context.Context, arg DeleteChirpParams) (int64, error) {
-	result, err := q.db.ExecContext(ctx, deleteChirp, arg.ID, arg.UserID)
+	result, err := q.db.ExecContext(ctx, deleteChirp, arg.ID, arg.UserID, arg.UpdatedAt)
 	if err != nil {
 		return 0, err
 	}
@@ -74,9 +81,47 @@ func (q *Queries) DeleteChirps(ctx context.Context) error {
 	return err
 }
 
+const exportChirpsByAuthor = `-- name: ExportChirpsByAuthor :many
+SELECT id, created_at, updated_at, body, user_id, deleted_at FROM chirps
+WHERE user_id = $1
+ORDER BY created_at ASC, id ASC
+`
+
+func (q *Queries) ExportChirpsByAuthor(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, exportChirpsByAuthor, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getChirp = `-- name: GetChirp :one
-SELECT id, created_at, updated_at, body, user_id FROM chirps
-WHERE id = $1 LIMIT 1
+SELECT id, created_at, updated_at, body, user_id, deleted_at FROM chirps
+WHERE id = $1
+  AND deleted_at IS NULL
+LIMIT 1
 `
 
 func (q *Queries) GetChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
@@ -88,14 +133,16 @@ func (q *Queries) GetChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
 const getChirpByAuthor = `-- name: GetChirpByAuthor :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+SELECT id, created_at, updated_at, body, user_id, deleted_at FROM chirps
 WHERE user_id = $1
-ORDER BY created_at ASC
+  AND deleted_at IS NULL
+ORDER BY created_at ASC, id ASC
 `
 
 func (q *Queries) GetChirpByAuthor(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
@@ -113,6 +160,59 @@ func (q *Queries) GetChirpByAuthor(ctx context.Context, userID uuid.UUID) ([]Chi
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpByAuthorForViewer = `-- name: GetChirpByAuthorForViewer :many
+SELECT id, created_at, updated_at, body, user_id, deleted_at FROM chirps
+WHERE user_id = $1
+AND deleted_at IS NULL
+AND NOT EXISTS (
+  SELECT 1 FROM user_mutes
+  WHERE user_mutes.muter_id = $2
+    AND user_mutes.muted_id = chirps.user_id
+)
+AND NOT EXISTS (
+  SELECT 1 FROM user_blocks
+  WHERE (user_blocks.blocker_id = $2 AND user_blocks.blocked_id = chirps.user_id)
+     OR (user_blocks.blocker_id = chirps.user_id AND user_blocks.blocked_id = $2)
+)
+ORDER BY created_at ASC, id ASC
+`
+
+type GetChirpByAuthorForViewerParams struct {
+	UserID  uuid.UUID
+	MuterID uuid.UUID
+}
+
+func (q *Queries) GetChirpByAuthorForViewer(ctx context.Context, arg GetChirpByAuthorForViewerParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpByAuthorForViewer, arg.UserID, arg.MuterID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.DeletedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -128,8 +228,9 @@ func (q *Queries) GetChirpByAuthor(ctx context.Context, userID uuid.UUID) ([]Chi
 }
 
 const getChirps = `-- name: GetChirps :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
-ORDER BY created_at ASC
+SELECT id, created_at, updated_at, body, user_id, deleted_at FROM chirps
+WHERE deleted_at IS NULL
+ORDER BY created_at ASC, id ASC
 `
 
 func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
@@ -147,6 +248,7 @@ func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.DeletedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -160,3 +262,129 @@ func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
 	}
 	return items, nil
 }
+
+const getChirpsForViewer = `-- name: GetChirpsForViewer :many
+SELECT id, created_at, updated_at, body, user_id, deleted_at FROM chirps
+WHERE deleted_at IS NULL
+AND NOT EXISTS (
+  SELECT 1 FROM user_mutes
+  WHERE user_mutes.muter_id = $1
+    AND user_mutes.muted_id = chirps.user_id
+)
+AND NOT EXISTS (
+  SELECT 1 FROM user_blocks
+  WHERE (user_blocks.blocker_id = $1 AND user_blocks.blocked_id = chirps.user_id)
+     OR (user_blocks.blocker_id = chirps.user_id AND user_blocks.blocked_id = $1)
+)
+ORDER BY created_at ASC, id ASC
+`
+
+func (q *Queries) GetChirpsForViewer(ctx context.Context, muterID uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsForViewer, muterID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertChirp = `-- name: InsertChirp :one
+INSERT INTO chirps (
+  id,
+  created_at,
+  updated_at,
+  body,
+  user_id
+) VALUES (
+  $1,
+  $2,
+  $2,
+  $3,
+  $4
+)
+RETURNING id, created_at, updated_at, body, user_id, deleted_at
+`
+
+type InsertChirpParams struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	Body      string
+	UserID    uuid.UUID
+}
+
+func (q *Queries) InsertChirp(ctx context.Context, arg InsertChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, insertChirp, arg.ID, arg.CreatedAt, arg.Body, arg.UserID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const purgeDeletedChirps = `-- name: PurgeDeletedChirps :execrows
+DELETE FROM chirps
+WHERE deleted_at < $1
+`
+
+func (q *Queries) PurgeDeletedChirps(ctx context.Context, deletedAt sql.NullTime) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeDeletedChirps, deletedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const restoreChirp = `-- name: RestoreChirp :one
+UPDATE chirps
+SET deleted_at = NULL
+WHERE id = $1
+  AND user_id = $2
+  AND deleted_at > $3
+RETURNING id, created_at, updated_at, body, user_id, deleted_at
+`
+
+type RestoreChirpParams struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	DeletedAt sql.NullTime
+}
+
+func (q *Queries) RestoreChirp(ctx context.Context, arg RestoreChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, restoreChirp, arg.ID, arg.UserID, arg.DeletedAt)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.DeletedAt,
+	)
+	return i, err
+}
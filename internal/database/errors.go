@@ -0,0 +1,40 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// ErrUniqueViolation is returned by Store implementations other than
+// Postgres when a write would break a unique constraint, such as two users
+// sharing an email.
+var ErrUniqueViolation = errors.New("database: unique constraint violation")
+
+// ErrForeignKeyViolation is returned by Store implementations other than
+// Postgres when a write references a row that does not exist.
+var ErrForeignKeyViolation = errors.New("database: foreign key violation")
+
+// Postgres SQLSTATE codes, see
+// https://www.postgresql.org/docs/current/errcodes-appendix.html.
+const (
+	pgUniqueViolation     = "23505"
+	pgForeignKeyViolation = "23503"
+)
+
+func hasPostgresCode(err error, code pq.ErrorCode) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == code
+}
+
+// IsUniqueViolation reports whether err is a unique constraint failure from
+// any backend.
+func IsUniqueViolation(err error) bool {
+	return errors.Is(err, ErrUniqueViolation) || hasPostgresCode(err, pgUniqueViolation)
+}
+
+// IsForeignKeyViolation reports whether err is a foreign key failure from
+// any backend.
+func IsForeignKeyViolation(err error) bool {
+	return errors.Is(err, ErrForeignKeyViolation) || hasPostgresCode(err, pgForeignKeyViolation)
+}
@@ -0,0 +1,863 @@
+// Package memstore is a thread-safe, in-memory database.Store. It mirrors
+// the Postgres schema's semantics closely enough for handler tests: unique
+// emails, cascading deletes from users, soft-deleted chirps and
+// sql.ErrNoRows for missing rows.
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ihyaulhaq/go-server/internal/database"
+)
+
+var _ database.Store = (*Store)(nil)
+
+type pair struct {
+	a, b uuid.UUID
+}
+
+type Store struct {
+	// txMu serializes WithTx calls; mu guards the data itself.
+	txMu sync.Mutex
+	mu   sync.RWMutex
+
+	users  map[uuid.UUID]database.User
+	emails map[string]uuid.UUID
+
+	// chirps is kept in insertion order, which doubles as created_at order.
+	chirps []database.Chirp
+	tokens map[string]database.RefreshToken
+
+	blocks map[pair]time.Time
+	mutes  map[pair]time.Time
+
+	auditEvents []database.AuditEvent
+	nextAuditID int64
+
+	idempotencyKeys map[idempotencyKey]database.IdempotencyKey
+	rateLimits      map[string]database.RateLimitBucket
+}
+
+type idempotencyKey struct {
+	scope, key string
+}
+
+func New() *Store {
+	return &Store{
+		users:  map[uuid.UUID]database.User{},
+		emails: map[string]uuid.UUID{},
+		tokens: map[string]database.RefreshToken{},
+		blocks: map[pair]time.Time{},
+		mutes:  map[pair]time.Time{},
+
+		idempotencyKeys: map[idempotencyKey]database.IdempotencyKey{},
+		rateLimits:      map[string]database.RateLimitBucket{},
+	}
+}
+
+// snapshot is a copy of a Store's data, used to roll back WithTx.
+type snapshot struct {
+	users       map[uuid.UUID]database.User
+	emails      map[string]uuid.UUID
+	chirps      []database.Chirp
+	tokens      map[string]database.RefreshToken
+	blocks      map[pair]time.Time
+	mutes       map[pair]time.Time
+	auditEvents []database.AuditEvent
+	nextAuditID int64
+
+	idempotencyKeys map[idempotencyKey]database.IdempotencyKey
+	rateLimits      map[string]database.RateLimitBucket
+}
+
+func (s *Store) snapshot() snapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return snapshot{
+		users:       maps.Clone(s.users),
+		emails:      maps.Clone(s.emails),
+		chirps:      slices.Clone(s.chirps),
+		tokens:      maps.Clone(s.tokens),
+		blocks:      maps.Clone(s.blocks),
+		mutes:       maps.Clone(s.mutes),
+		auditEvents: slices.Clone(s.auditEvents),
+		nextAuditID: s.nextAuditID,
+
+		idempotencyKeys: maps.Clone(s.idempotencyKeys),
+		rateLimits:      maps.Clone(s.rateLimits),
+	}
+}
+
+func (s *Store) restore(snap snapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.users = snap.users
+	s.emails = snap.emails
+	s.chirps = snap.chirps
+	s.tokens = snap.tokens
+	s.blocks = snap.blocks
+	s.mutes = snap.mutes
+	s.auditEvents = snap.auditEvents
+	s.nextAuditID = snap.nextAuditID
+	s.idempotencyKeys = snap.idempotencyKeys
+	s.rateLimits = snap.rateLimits
+}
+
+// WithTx runs fn against the store and restores its previous contents if fn
+// fails. Transactions are serialized with each other but, unlike a real
+// database, are not isolated from concurrent writes outside WithTx.
+func (s *Store) WithTx(ctx context.Context, fn func(database.Store) error) error {
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	snap := s.snapshot()
+	if err := fn(txStore{s}); err != nil {
+		s.restore(snap)
+		return err
+	}
+	return nil
+}
+
+// txStore is the Store passed to a WithTx callback. Calls to its WithTx join
+// the running transaction, as they do on the SQL backends, rather than
+// waiting on txMu.
+type txStore struct {
+	*Store
+}
+
+func (tx txStore) WithTx(ctx context.Context, fn func(database.Store) error) error {
+	return fn(tx)
+}
+
+func now() time.Time {
+	return time.Now().UTC()
+}
+
+// deleteUserLocked removes a user and everything that references it.
+func (s *Store) deleteUserLocked(id uuid.UUID) {
+	user, ok := s.users[id]
+	if !ok {
+		return
+	}
+	delete(s.users, id)
+	delete(s.emails, user.Email)
+
+	s.chirps = slices.DeleteFunc(s.chirps, func(c database.Chirp) bool {
+		return c.UserID == id
+	})
+	for token, t := range s.tokens {
+		if t.UserID == id {
+			delete(s.tokens, token)
+		}
+	}
+	for p := range s.blocks {
+		if p.a == id || p.b == id {
+			delete(s.blocks, p)
+		}
+	}
+	for p := range s.mutes {
+		if p.a == id || p.b == id {
+			delete(s.mutes, p)
+		}
+	}
+}
+
+func (s *Store) updateUserLocked(id uuid.UUID, fn func(u *database.User)) (database.User, error) {
+	user, ok := s.users[id]
+	if !ok {
+		return database.User{}, sql.ErrNoRows
+	}
+	fn(&user)
+	user.UpdatedAt = now()
+	s.users[id] = user
+	return user, nil
+}
+
+// revokeUserTokensLocked revokes every live refresh token of a user, as the
+// suspend, ban and delete queries do.
+func (s *Store) revokeUserTokensLocked(userID uuid.UUID) {
+	for token, t := range s.tokens {
+		if t.UserID == userID && !t.RevokedAt.Valid {
+			t.RevokedAt = sql.NullTime{Time: now(), Valid: true}
+			t.UpdatedAt = t.RevokedAt.Time
+			s.tokens[token] = t
+		}
+	}
+}
+
+func (s *Store) updateUser(id uuid.UUID, fn func(u *database.User)) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.updateUserLocked(id, fn)
+}
+
+func (s *Store) chirpIndexLocked(id uuid.UUID) int {
+	return slices.IndexFunc(s.chirps, func(c database.Chirp) bool {
+		return c.ID == id
+	})
+}
+
+func (s *Store) filterChirps(keep func(c database.Chirp) bool) []database.Chirp {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []database.Chirp
+	for _, c := range s.chirps {
+		if keep(c) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// Users
+
+func (s *Store) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, taken := s.emails[arg.Email]; taken {
+		return database.User{}, database.ErrUniqueViolation
+	}
+
+	t := now()
+	user := database.User{
+		ID:             uuid.New(),
+		CreatedAt:      t,
+		UpdatedAt:      t,
+		Email:          arg.Email,
+		HashedPassword: arg.HashedPassword,
+	}
+	s.users[user.ID] = user
+	s.emails[user.Email] = user.ID
+	return user, nil
+}
+
+func (s *Store) InsertUser(ctx context.Context, arg database.InsertUserParams) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, taken := s.users[arg.ID]; taken {
+		return database.User{}, database.ErrUniqueViolation
+	}
+	if _, taken := s.emails[arg.Email]; taken {
+		return database.User{}, database.ErrUniqueViolation
+	}
+
+	user := database.User{
+		ID:             arg.ID,
+		CreatedAt:      arg.CreatedAt,
+		UpdatedAt:      arg.CreatedAt,
+		Email:          arg.Email,
+		HashedPassword: arg.HashedPassword,
+		IsChirpyRed:    arg.IsChirpyRed,
+	}
+	s.users[user.ID] = user
+	s.emails[user.Email] = user.ID
+	return user, nil
+}
+
+func (s *Store) GetUserByEmail(ctx context.Context, email string) (database.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	id, ok := s.emails[email]
+	if !ok {
+		return database.User{}, sql.ErrNoRows
+	}
+	return s.users[id], nil
+}
+
+func (s *Store) GetUserByID(ctx context.Context, id uuid.UUID) (database.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return database.User{}, sql.ErrNoRows
+	}
+	return user, nil
+}
+
+func (s *Store) UpdateUser(ctx context.Context, arg database.UpdateUserParams) (database.UpdateUserRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[arg.ID]
+	if !ok {
+		return database.UpdateUserRow{}, sql.ErrNoRows
+	}
+	if owner, taken := s.emails[arg.Email]; taken && owner != arg.ID {
+		return database.UpdateUserRow{}, database.ErrUniqueViolation
+	}
+
+	delete(s.emails, user.Email)
+	user, _ = s.updateUserLocked(arg.ID, func(u *database.User) {
+		u.Email = arg.Email
+		u.HashedPassword = arg.HashedPassword
+	})
+	s.emails[user.Email] = user.ID
+
+	return database.UpdateUserRow{
+		ID:          user.ID,
+		CreatedAt:   user.CreatedAt,
+		UpdatedAt:   user.UpdatedAt,
+		Email:       user.Email,
+		IsChirpyRed: user.IsChirpyRed,
+	}, nil
+}
+
+func (s *Store) UpgradeUserToChirpyRed(ctx context.Context, id uuid.UUID) (database.UpgradeUserToChirpyRedRow, error) {
+	user, err := s.updateUser(id, func(u *database.User) {
+		u.IsChirpyRed = true
+	})
+	if err != nil {
+		return database.UpgradeUserToChirpyRedRow{}, err
+	}
+
+	return database.UpgradeUserToChirpyRedRow{
+		ID:          user.ID,
+		CreatedAt:   user.CreatedAt,
+		UpdatedAt:   user.UpdatedAt,
+		Email:       user.Email,
+		IsChirpyRed: user.IsChirpyRed,
+	}, nil
+}
+
+func (s *Store) SuspendUser(ctx context.Context, arg database.SuspendUserParams) (database.User, error) {
+	return s.updateUser(arg.ID, func(u *database.User) {
+		u.SuspendedUntil = arg.SuspendedUntil
+		u.SuspensionReason = arg.SuspensionReason
+		s.revokeUserTokensLocked(u.ID)
+	})
+}
+
+func (s *Store) UnsuspendUser(ctx context.Context, id uuid.UUID) (database.User, error) {
+	return s.updateUser(id, func(u *database.User) {
+		u.SuspendedUntil = sql.NullTime{}
+		u.SuspensionReason = sql.NullString{}
+	})
+}
+
+func (s *Store) BanUser(ctx context.Context, arg database.BanUserParams) (database.User, error) {
+	return s.updateUser(arg.ID, func(u *database.User) {
+		u.BannedAt = sql.NullTime{Time: now(), Valid: true}
+		u.BanReason = arg.BanReason
+		s.revokeUserTokensLocked(u.ID)
+	})
+}
+
+func (s *Store) UnbanUser(ctx context.Context, id uuid.UUID) (database.User, error) {
+	return s.updateUser(id, func(u *database.User) {
+		u.BannedAt = sql.NullTime{}
+		u.BanReason = sql.NullString{}
+	})
+}
+
+func (s *Store) ScheduleUserDeletion(ctx context.Context, arg database.ScheduleUserDeletionParams) (database.User, error) {
+	return s.updateUser(arg.ID, func(u *database.User) {
+		u.DeletionScheduledAt = arg.DeletionScheduledAt
+		s.revokeUserTokensLocked(u.ID)
+	})
+}
+
+func (s *Store) CancelUserDeletion(ctx context.Context, id uuid.UUID) (database.User, error) {
+	return s.updateUser(id, func(u *database.User) {
+		u.DeletionScheduledAt = sql.NullTime{}
+	})
+}
+
+func (s *Store) PurgeScheduledUserDeletions(ctx context.Context, deletionScheduledAt sql.NullTime) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !deletionScheduledAt.Valid {
+		return 0, nil
+	}
+
+	var rows int64
+	for id, u := range s.users {
+		if u.DeletionScheduledAt.Valid && u.DeletionScheduledAt.Time.Before(deletionScheduledAt.Time) {
+			s.deleteUserLocked(id)
+			rows++
+		}
+	}
+	return rows, nil
+}
+
+func (s *Store) DeleteUsers(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id := range s.users {
+		s.deleteUserLocked(id)
+	}
+	return nil
+}
+
+// Chirps
+
+func (s *Store) CreateChirps(ctx context.Context, arg database.CreateChirpsParams) (database.Chirp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[arg.UserID]; !ok {
+		return database.Chirp{}, database.ErrForeignKeyViolation
+	}
+
+	t := now()
+	chirp := database.Chirp{
+		ID:        uuid.New(),
+		CreatedAt: t,
+		UpdatedAt: t,
+		Body:      arg.Body,
+		UserID:    arg.UserID,
+	}
+	s.chirps = append(s.chirps, chirp)
+	return chirp, nil
+}
+
+// InsertChirp adds a chirp with a caller-chosen ID and creation time,
+// keeping chirps in created_at order.
+func (s *Store) InsertChirp(ctx context.Context, arg database.InsertChirpParams) (database.Chirp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[arg.UserID]; !ok {
+		return database.Chirp{}, database.ErrForeignKeyViolation
+	}
+	if s.chirpIndexLocked(arg.ID) >= 0 {
+		return database.Chirp{}, database.ErrUniqueViolation
+	}
+
+	chirp := database.Chirp{
+		ID:        arg.ID,
+		CreatedAt: arg.CreatedAt,
+		UpdatedAt: arg.CreatedAt,
+		Body:      arg.Body,
+		UserID:    arg.UserID,
+	}
+
+	i := len(s.chirps)
+	for i > 0 && s.chirps[i-1].CreatedAt.After(chirp.CreatedAt) {
+		i--
+	}
+	s.chirps = slices.Insert(s.chirps, i, chirp)
+	return chirp, nil
+}
+
+func (s *Store) GetChirp(ctx context.Context, id uuid.UUID) (database.Chirp, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	i := s.chirpIndexLocked(id)
+	if i < 0 || s.chirps[i].DeletedAt.Valid {
+		return database.Chirp{}, sql.ErrNoRows
+	}
+	return s.chirps[i], nil
+}
+
+func (s *Store) GetChirps(ctx context.Context) ([]database.Chirp, error) {
+	return s.filterChirps(func(c database.Chirp) bool {
+		return !c.DeletedAt.Valid
+	}), nil
+}
+
+func (s *Store) GetChirpByAuthor(ctx context.Context, userID uuid.UUID) ([]database.Chirp, error) {
+	return s.filterChirps(func(c database.Chirp) bool {
+		return !c.DeletedAt.Valid && c.UserID == userID
+	}), nil
+}
+
+// hiddenFromLocked reports whether viewer muted or blocked author, or
+// author blocked viewer.
+func (s *Store) hiddenFromLocked(viewer, author uuid.UUID) bool {
+	_, muted := s.mutes[pair{viewer, author}]
+	_, blocked := s.blocks[pair{viewer, author}]
+	_, blockedBy := s.blocks[pair{author, viewer}]
+	return muted || blocked || blockedBy
+}
+
+func (s *Store) GetChirpsForViewer(ctx context.Context, muterID uuid.UUID) ([]database.Chirp, error) {
+	return s.filterChirps(func(c database.Chirp) bool {
+		return !c.DeletedAt.Valid && !s.hiddenFromLocked(muterID, c.UserID)
+	}), nil
+}
+
+func (s *Store) GetChirpByAuthorForViewer(ctx context.Context, arg database.GetChirpByAuthorForViewerParams) ([]database.Chirp, error) {
+	return s.filterChirps(func(c database.Chirp) bool {
+		return !c.DeletedAt.Valid && c.UserID == arg.UserID && !s.hiddenFromLocked(arg.MuterID, c.UserID)
+	}), nil
+}
+
+func (s *Store) ExportChirpsByAuthor(ctx context.Context, userID uuid.UUID) ([]database.Chirp, error) {
+	return s.filterChirps(func(c database.Chirp) bool {
+		return c.UserID == userID
+	}), nil
+}
+
+func (s *Store) DeleteChirp(ctx context.Context, arg database.DeleteChirpParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.chirpIndexLocked(arg.ID)
+	if i < 0 || s.chirps[i].UserID != arg.UserID || s.chirps[i].DeletedAt.Valid {
+		return 0, nil
+	}
+	if arg.UpdatedAt.Valid && !s.chirps[i].UpdatedAt.Equal(arg.UpdatedAt.Time) {
+		return 0, nil
+	}
+	s.chirps[i].DeletedAt = sql.NullTime{Time: now(), Valid: true}
+	return 1, nil
+}
+
+func (s *Store) RestoreChirp(ctx context.Context, arg database.RestoreChirpParams) (database.Chirp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.chirpIndexLocked(arg.ID)
+	if i < 0 || s.chirps[i].UserID != arg.UserID || !arg.DeletedAt.Valid {
+		return database.Chirp{}, sql.ErrNoRows
+	}
+
+	deletedAt := s.chirps[i].DeletedAt
+	if !deletedAt.Valid || !deletedAt.Time.After(arg.DeletedAt.Time) {
+		return database.Chirp{}, sql.ErrNoRows
+	}
+
+	s.chirps[i].DeletedAt = sql.NullTime{}
+	return s.chirps[i], nil
+}
+
+func (s *Store) PurgeDeletedChirps(ctx context.Context, deletedAt sql.NullTime) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !deletedAt.Valid {
+		return 0, nil
+	}
+
+	before := len(s.chirps)
+	s.chirps = slices.DeleteFunc(s.chirps, func(c database.Chirp) bool {
+		return c.DeletedAt.Valid && c.DeletedAt.Time.Before(deletedAt.Time)
+	})
+	return int64(before - len(s.chirps)), nil
+}
+
+func (s *Store) DeleteChirps(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.chirps = nil
+	return nil
+}
+
+// Refresh tokens
+
+func (s *Store) CreateRefreshToken(ctx context.Context, arg database.CreateRefreshTokenParams) (database.RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[arg.UserID]; !ok {
+		return database.RefreshToken{}, database.ErrForeignKeyViolation
+	}
+	if _, taken := s.tokens[arg.Token]; taken {
+		return database.RefreshToken{}, database.ErrUniqueViolation
+	}
+
+	t := now()
+	token := database.RefreshToken{
+		Token:     arg.Token,
+		CreatedAt: t,
+		UpdatedAt: t,
+		UserID:    arg.UserID,
+		ExpiresAt: arg.ExpiresAt,
+	}
+	s.tokens[token.Token] = token
+	return token, nil
+}
+
+func (s *Store) GetRefreshToken(ctx context.Context, token string) (database.RefreshToken, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, ok := s.tokens[token]
+	if !ok {
+		return database.RefreshToken{}, sql.ErrNoRows
+	}
+	return t, nil
+}
+
+func (s *Store) GetRefreshTokensByUser(ctx context.Context, userID uuid.UUID) ([]database.RefreshToken, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []database.RefreshToken
+	for _, t := range s.tokens {
+		if t.UserID == userID {
+			out = append(out, t)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].CreatedAt.Before(out[j].CreatedAt)
+	})
+	return out, nil
+}
+
+func (s *Store) DeleteRefreshTokens(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	clear(s.tokens)
+	return nil
+}
+
+func (s *Store) RevokeRefreshToken(ctx context.Context, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tokens[token]
+	if !ok || t.RevokedAt.Valid {
+		return nil
+	}
+	t.RevokedAt = sql.NullTime{Time: now(), Valid: true}
+	t.UpdatedAt = t.RevokedAt.Time
+	s.tokens[token] = t
+	return nil
+}
+
+// Blocks and mutes
+
+func (s *Store) addRelationship(m map[pair]time.Time, a, b uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, okA := s.users[a]
+	_, okB := s.users[b]
+	if !okA || !okB {
+		return database.ErrForeignKeyViolation
+	}
+	if _, exists := m[pair{a, b}]; !exists {
+		m[pair{a, b}] = now()
+	}
+	return nil
+}
+
+func (s *Store) removeRelationship(m map[pair]time.Time, a, b uuid.UUID) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := m[pair{a, b}]; !exists {
+		return 0
+	}
+	delete(m, pair{a, b})
+	return 1
+}
+
+func (s *Store) BlockUser(ctx context.Context, arg database.BlockUserParams) error {
+	return s.addRelationship(s.blocks, arg.BlockerID, arg.BlockedID)
+}
+
+func (s *Store) UnblockUser(ctx context.Context, arg database.UnblockUserParams) (int64, error) {
+	return s.removeRelationship(s.blocks, arg.BlockerID, arg.BlockedID), nil
+}
+
+func (s *Store) IsBlocked(ctx context.Context, arg database.IsBlockedParams) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, blocked := s.blocks[pair{arg.BlockerID, arg.BlockedID}]
+	return blocked, nil
+}
+
+func (s *Store) GetHiddenAuthorIDs(ctx context.Context, muterID uuid.UUID) ([]uuid.UUID, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	hidden := map[uuid.UUID]struct{}{}
+	for p := range s.mutes {
+		if p.a == muterID {
+			hidden[p.b] = struct{}{}
+		}
+	}
+	for p := range s.blocks {
+		switch muterID {
+		case p.a:
+			hidden[p.b] = struct{}{}
+		case p.b:
+			hidden[p.a] = struct{}{}
+		}
+	}
+	return slices.Collect(maps.Keys(hidden)), nil
+}
+
+func (s *Store) MuteUser(ctx context.Context, arg database.MuteUserParams) error {
+	return s.addRelationship(s.mutes, arg.MuterID, arg.MutedID)
+}
+
+func (s *Store) UnmuteUser(ctx context.Context, arg database.UnmuteUserParams) (int64, error) {
+	return s.removeRelationship(s.mutes, arg.MuterID, arg.MutedID), nil
+}
+
+// Audit events
+
+func (s *Store) CreateAuditEvent(ctx context.Context, arg database.CreateAuditEventParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextAuditID++
+	s.auditEvents = append(s.auditEvents, database.AuditEvent{
+		ID:        s.nextAuditID,
+		CreatedAt: arg.CreatedAt,
+		ActorID:   arg.ActorID,
+		Action:    arg.Action,
+		Target:    arg.Target,
+		Ip:        arg.Ip,
+		UserAgent: arg.UserAgent,
+	})
+	return nil
+}
+
+func (s *Store) ListAuditEvents(ctx context.Context, arg database.ListAuditEventsParams) ([]database.AuditEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var matched []database.AuditEvent
+	for i := len(s.auditEvents) - 1; i >= 0; i-- {
+		e := s.auditEvents[i]
+		if arg.ActorID.Valid && (!e.ActorID.Valid || e.ActorID.UUID != arg.ActorID.UUID) {
+			continue
+		}
+		if arg.Action.Valid && e.Action != arg.Action.String {
+			continue
+		}
+		if arg.Since.Valid && e.CreatedAt.Before(arg.Since.Time) {
+			continue
+		}
+		if arg.Until.Valid && !e.CreatedAt.Before(arg.Until.Time) {
+			continue
+		}
+		matched = append(matched, e)
+	}
+
+	offset := min(int(arg.Offset), len(matched))
+	end := min(offset+int(arg.Limit), len(matched))
+	return matched[offset:end], nil
+}
+
+// Idempotency keys
+
+func (s *Store) ClaimIdempotencyKey(ctx context.Context, arg database.ClaimIdempotencyKeyParams) (database.IdempotencyKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := now()
+	id := idempotencyKey{arg.Scope, arg.Key}
+	if existing, ok := s.idempotencyKeys[id]; ok {
+		expired := existing.ExpiresAt.Before(t)
+		stale := !existing.Status.Valid && existing.CreatedAt.Before(arg.StaleBefore)
+		if !expired && !stale {
+			return database.IdempotencyKey{}, sql.ErrNoRows
+		}
+	}
+
+	key := database.IdempotencyKey{
+		Scope:       arg.Scope,
+		Key:         arg.Key,
+		RequestHash: arg.RequestHash,
+		CreatedAt:   t,
+		ExpiresAt:   arg.ExpiresAt,
+	}
+	s.idempotencyKeys[id] = key
+	return key, nil
+}
+
+func (s *Store) GetIdempotencyKey(ctx context.Context, arg database.GetIdempotencyKeyParams) (database.IdempotencyKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key, ok := s.idempotencyKeys[idempotencyKey{arg.Scope, arg.Key}]
+	if !ok {
+		return database.IdempotencyKey{}, sql.ErrNoRows
+	}
+	return key, nil
+}
+
+func (s *Store) CompleteIdempotencyKey(ctx context.Context, arg database.CompleteIdempotencyKeyParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := idempotencyKey{arg.Scope, arg.Key}
+	key, ok := s.idempotencyKeys[id]
+	if !ok {
+		return nil
+	}
+	key.Status = arg.Status
+	key.Headers = arg.Headers
+	key.Body = slices.Clone(arg.Body)
+	s.idempotencyKeys[id] = key
+	return nil
+}
+
+func (s *Store) DeleteIdempotencyKey(ctx context.Context, arg database.DeleteIdempotencyKeyParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.idempotencyKeys, idempotencyKey{arg.Scope, arg.Key})
+	return nil
+}
+
+func (s *Store) PurgeIdempotencyKeys(ctx context.Context, expiresAt time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	before := len(s.idempotencyKeys)
+	maps.DeleteFunc(s.idempotencyKeys, func(_ idempotencyKey, key database.IdempotencyKey) bool {
+		return key.ExpiresAt.Before(expiresAt)
+	})
+	return int64(before - len(s.idempotencyKeys)), nil
+}
+
+// Rate limits
+
+func (s *Store) TakeRateLimitToken(ctx context.Context, arg database.TakeRateLimitTokenParams) (database.RateLimitBucket, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := now()
+	b, ok := s.rateLimits[arg.Key]
+	if !ok {
+		b = database.RateLimitBucket{Key: arg.Key, Tokens: arg.Capacity, UpdatedAt: t}
+	}
+
+	tokens := min(arg.Capacity, b.Tokens+t.Sub(b.UpdatedAt).Seconds()*arg.RefillRate)
+	b.Allowed = tokens >= 1
+	if b.Allowed {
+		tokens--
+	}
+	b.Tokens = tokens
+	b.UpdatedAt = t
+
+	s.rateLimits[arg.Key] = b
+	return b, nil
+}
+
+func (s *Store) PurgeRateLimitBuckets(ctx context.Context, updatedAt time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	before := len(s.rateLimits)
+	maps.DeleteFunc(s.rateLimits, func(_ string, b database.RateLimitBucket) bool {
+		return b.UpdatedAt.Before(updatedAt)
+	})
+	return int64(before - len(s.rateLimits)), nil
+}
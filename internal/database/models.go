@@ -11,12 +11,41 @@ import (
 	"github.com/google/uuid"
 )
 
+type AuditEvent struct {
+	ID        int64
+	CreatedAt time.Time
+	ActorID   uuid.NullUUID
+	Action    string
+	Target    string
+	Ip        string
+	UserAgent string
+}
+
 type Chirp struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	Body      string
 	UserID    uuid.UUID
+	DeletedAt sql.NullTime
+}
+
+type IdempotencyKey struct {
+	Scope       string
+	Key         string
+	RequestHash string
+	Status      sql.NullInt32
+	Headers     sql.NullString
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+type RateLimitBucket struct {
+	Key       string
+	Tokens    float64
+	Allowed   bool
+	UpdatedAt time.Time
 }
 
 type RefreshToken struct {
@@ -29,10 +58,27 @@ type RefreshToken struct {
 }
 
 type User struct {
-	ID             uuid.UUID
-	CreatedAt      time.Time
-	UpdatedAt      time.Time
-	Email          string
-	HashedPassword string
-	IsChirpyRed    bool
+	ID                  uuid.UUID
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	Email               string
+	HashedPassword      string
+	IsChirpyRed         bool
+	SuspendedUntil      sql.NullTime
+	SuspensionReason    sql.NullString
+	BannedAt            sql.NullTime
+	BanReason           sql.NullString
+	DeletionScheduledAt sql.NullTime
+}
+
+type UserBlock struct {
+	BlockerID uuid.UUID
+	BlockedID uuid.UUID
+	CreatedAt time.Time
+}
+
+type UserMute struct {
+	MuterID   uuid.UUID
+	MutedID   uuid.UUID
+	CreatedAt time.Time
 }
@@ -0,0 +1,28 @@
+package sqlitedb
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/ihyaulhaq/go-server/internal/database"
+)
+
+// connParams are appended to every DSN. The queries compare timestamps
+// numerically, so times must be written as Unix nanoseconds and read back
+// from integers.
+const connParams = "_pragma=foreign_keys(1)" +
+	"&_pragma=journal_mode(WAL)" +
+	"&_pragma=busy_timeout(5000)" +
+	"&_time_integer_format=unix_nano" +
+	"&_inttotime=1"
+
+// Open opens the SQLite database file at path with the connection settings
+// the schema and queries rely on.
+func Open(path string) (*sql.DB, error) {
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+
+	return sql.Open(database.DriverSQLite, path+sep+connParams)
+}
@@ -0,0 +1,358 @@
+package sqlitedb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ihyaulhaq/go-server/internal/database"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+var _ database.Store = (*Store)(nil)
+
+// Store adapts the generated SQLite queries to database.Store. The SQLite
+// models mirror the Postgres ones field for field, so most methods are
+// plain type conversions.
+type Store struct {
+	q  *Queries
+	db *sql.DB
+	tx *sql.Tx
+}
+
+// NewStore returns a Store over db, tracing every query.
+func NewStore(db *sql.DB) *Store {
+	return &Store{
+		q:  New(database.NewTracedDBTX(db, "sqlite")),
+		db: db,
+	}
+}
+
+// WithTx runs fn against a Store bound to a single transaction. Calls made
+// on a Store that is already in a transaction join it.
+func (s *Store) WithTx(ctx context.Context, fn func(database.Store) error) error {
+	if s.tx != nil {
+		return fn(s)
+	}
+
+	return database.RunInTx(ctx, s.db, func(tx *sql.Tx) error {
+		return fn(&Store{
+			q:  New(database.NewTracedDBTX(tx, "sqlite")),
+			db: s.db,
+			tx: tx,
+		})
+	})
+}
+
+// inTx runs fn with queries bound to a transaction, joining the current one
+// if there is one. SQLite can't update in a CTE, so statements that
+// Postgres combines run here together.
+func (s *Store) inTx(ctx context.Context, fn func(q *Queries) error) error {
+	return s.WithTx(ctx, func(tx database.Store) error {
+		return fn(tx.(*Store).q)
+	})
+}
+
+// mapError translates SQLite constraint failures into the database package's
+// sentinel errors.
+func mapError(err error) error {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return err
+	}
+
+	switch sqliteErr.Code() {
+	case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+		return fmt.Errorf("%w: %v", database.ErrUniqueViolation, err)
+	case sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY:
+		return fmt.Errorf("%w: %v", database.ErrForeignKeyViolation, err)
+	}
+	return err
+}
+
+func convertAll[From, To any](items []From, err error, convert func(From) To) ([]To, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]To, 0, len(items))
+	for _, item := range items {
+		out = append(out, convert(item))
+	}
+	return out, nil
+}
+
+func toChirp(c Chirp) database.Chirp {
+	return database.Chirp(c)
+}
+
+func toAuditEvent(e AuditEvent) database.AuditEvent {
+	return database.AuditEvent(e)
+}
+
+func toRefreshToken(t RefreshToken) database.RefreshToken {
+	return database.RefreshToken(t)
+}
+
+// Users
+
+func (s *Store) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	user, err := s.q.CreateUser(ctx, CreateUserParams(arg))
+	return database.User(user), mapError(err)
+}
+
+func (s *Store) GetUserByEmail(ctx context.Context, email string) (database.User, error) {
+	user, err := s.q.GetUserByEmail(ctx, email)
+	return database.User(user), err
+}
+
+func (s *Store) GetUserByID(ctx context.Context, id uuid.UUID) (database.User, error) {
+	user, err := s.q.GetUserByID(ctx, id)
+	return database.User(user), err
+}
+
+func (s *Store) UpdateUser(ctx context.Context, arg database.UpdateUserParams) (database.UpdateUserRow, error) {
+	row, err := s.q.UpdateUser(ctx, UpdateUserParams(arg))
+	return database.UpdateUserRow(row), mapError(err)
+}
+
+func (s *Store) UpgradeUserToChirpyRed(ctx context.Context, id uuid.UUID) (database.UpgradeUserToChirpyRedRow, error) {
+	row, err := s.q.UpgradeUserToChirpyRed(ctx, id)
+	return database.UpgradeUserToChirpyRedRow(row), err
+}
+
+// SuspendUser also revokes the user's refresh tokens, as the Postgres
+// query does.
+func (s *Store) SuspendUser(ctx context.Context, arg database.SuspendUserParams) (database.User, error) {
+	var user User
+	err := s.inTx(ctx, func(q *Queries) error {
+		var err error
+		if user, err = q.SuspendUser(ctx, SuspendUserParams(arg)); err != nil {
+			return err
+		}
+		return q.RevokeUserRefreshTokens(ctx, arg.ID)
+	})
+	return database.User(user), err
+}
+
+func (s *Store) UnsuspendUser(ctx context.Context, id uuid.UUID) (database.User, error) {
+	user, err := s.q.UnsuspendUser(ctx, id)
+	return database.User(user), err
+}
+
+// BanUser also revokes the user's refresh tokens.
+func (s *Store) BanUser(ctx context.Context, arg database.BanUserParams) (database.User, error) {
+	var user User
+	err := s.inTx(ctx, func(q *Queries) error {
+		var err error
+		if user, err = q.BanUser(ctx, BanUserParams(arg)); err != nil {
+			return err
+		}
+		return q.RevokeUserRefreshTokens(ctx, arg.ID)
+	})
+	return database.User(user), err
+}
+
+func (s *Store) UnbanUser(ctx context.Context, id uuid.UUID) (database.User, error) {
+	user, err := s.q.UnbanUser(ctx, id)
+	return database.User(user), err
+}
+
+// ScheduleUserDeletion also revokes the user's refresh tokens.
+func (s *Store) ScheduleUserDeletion(ctx context.Context, arg database.ScheduleUserDeletionParams) (database.User, error) {
+	var user User
+	err := s.inTx(ctx, func(q *Queries) error {
+		var err error
+		if user, err = q.ScheduleUserDeletion(ctx, ScheduleUserDeletionParams(arg)); err != nil {
+			return err
+		}
+		return q.RevokeUserRefreshTokens(ctx, arg.ID)
+	})
+	return database.User(user), err
+}
+
+func (s *Store) CancelUserDeletion(ctx context.Context, id uuid.UUID) (database.User, error) {
+	user, err := s.q.CancelUserDeletion(ctx, id)
+	return database.User(user), err
+}
+
+func (s *Store) PurgeScheduledUserDeletions(ctx context.Context, deletionScheduledAt sql.NullTime) (int64, error) {
+	return s.q.PurgeScheduledUserDeletions(ctx, deletionScheduledAt)
+}
+
+func (s *Store) DeleteUsers(ctx context.Context) error {
+	return s.q.DeleteUsers(ctx)
+}
+
+func (s *Store) InsertUser(ctx context.Context, arg database.InsertUserParams) (database.User, error) {
+	user, err := s.q.InsertUser(ctx, InsertUserParams(arg))
+	return database.User(user), mapError(err)
+}
+
+// Chirps
+
+func (s *Store) CreateChirps(ctx context.Context, arg database.CreateChirpsParams) (database.Chirp, error) {
+	chirp, err := s.q.CreateChirps(ctx, CreateChirpsParams(arg))
+	return database.Chirp(chirp), mapError(err)
+}
+
+func (s *Store) InsertChirp(ctx context.Context, arg database.InsertChirpParams) (database.Chirp, error) {
+	chirp, err := s.q.InsertChirp(ctx, InsertChirpParams(arg))
+	return database.Chirp(chirp), mapError(err)
+}
+
+func (s *Store) GetChirp(ctx context.Context, id uuid.UUID) (database.Chirp, error) {
+	chirp, err := s.q.GetChirp(ctx, id)
+	return database.Chirp(chirp), err
+}
+
+func (s *Store) GetChirps(ctx context.Context) ([]database.Chirp, error) {
+	chirps, err := s.q.GetChirps(ctx)
+	return convertAll(chirps, err, toChirp)
+}
+
+func (s *Store) GetChirpByAuthor(ctx context.Context, userID uuid.UUID) ([]database.Chirp, error) {
+	chirps, err := s.q.GetChirpByAuthor(ctx, userID)
+	return convertAll(chirps, err, toChirp)
+}
+
+func (s *Store) GetChirpsForViewer(ctx context.Context, muterID uuid.UUID) ([]database.Chirp, error) {
+	chirps, err := s.q.GetChirpsForViewer(ctx, muterID)
+	return convertAll(chirps, err, toChirp)
+}
+
+func (s *Store) GetChirpByAuthorForViewer(ctx context.Context, arg database.GetChirpByAuthorForViewerParams) ([]database.Chirp, error) {
+	chirps, err := s.q.GetChirpByAuthorForViewer(ctx, GetChirpByAuthorForViewerParams(arg))
+	return convertAll(chirps, err, toChirp)
+}
+
+func (s *Store) ExportChirpsByAuthor(ctx context.Context, userID uuid.UUID) ([]database.Chirp, error) {
+	chirps, err := s.q.ExportChirpsByAuthor(ctx, userID)
+	return convertAll(chirps, err, toChirp)
+}
+
+func (s *Store) DeleteChirp(ctx context.Context, arg database.DeleteChirpParams) (int64, error) {
+	return s.q.DeleteChirp(ctx, DeleteChirpParams(arg))
+}
+
+func (s *Store) RestoreChirp(ctx context.Context, arg database.RestoreChirpParams) (database.Chirp, error) {
+	chirp, err := s.q.RestoreChirp(ctx, RestoreChirpParams(arg))
+	return database.Chirp(chirp), err
+}
+
+func (s *Store) PurgeDeletedChirps(ctx context.Context, deletedAt sql.NullTime) (int64, error) {
+	return s.q.PurgeDeletedChirps(ctx, deletedAt)
+}
+
+func (s *Store) DeleteChirps(ctx context.Context) error {
+	return s.q.DeleteChirps(ctx)
+}
+
+// Refresh tokens
+
+func (s *Store) CreateRefreshToken(ctx context.Context, arg database.CreateRefreshTokenParams) (database.RefreshToken, error) {
+	token, err := s.q.CreateRefreshToken(ctx, CreateRefreshTokenParams(arg))
+	return database.RefreshToken(token), mapError(err)
+}
+
+func (s *Store) GetRefreshToken(ctx context.Context, token string) (database.RefreshToken, error) {
+	t, err := s.q.GetRefreshToken(ctx, token)
+	return database.RefreshToken(t), err
+}
+
+func (s *Store) GetRefreshTokensByUser(ctx context.Context, userID uuid.UUID) ([]database.RefreshToken, error) {
+	tokens, err := s.q.GetRefreshTokensByUser(ctx, userID)
+	return convertAll(tokens, err, toRefreshToken)
+}
+
+func (s *Store) DeleteRefreshTokens(ctx context.Context) error {
+	return s.q.DeleteRefreshTokens(ctx)
+}
+
+func (s *Store) RevokeRefreshToken(ctx context.Context, token string) error {
+	return s.q.RevokeRefreshToken(ctx, token)
+}
+
+// Blocks and mutes
+
+func (s *Store) BlockUser(ctx context.Context, arg database.BlockUserParams) error {
+	return mapError(s.q.BlockUser(ctx, BlockUserParams(arg)))
+}
+
+func (s *Store) UnblockUser(ctx context.Context, arg database.UnblockUserParams) (int64, error) {
+	return s.q.UnblockUser(ctx, UnblockUserParams(arg))
+}
+
+func (s *Store) IsBlocked(ctx context.Context, arg database.IsBlockedParams) (bool, error) {
+	blocked, err := s.q.IsBlocked(ctx, IsBlockedParams(arg))
+	return blocked != 0, err
+}
+
+func (s *Store) GetHiddenAuthorIDs(ctx context.Context, muterID uuid.UUID) ([]uuid.UUID, error) {
+	return s.q.GetHiddenAuthorIDs(ctx, muterID)
+}
+
+func (s *Store) MuteUser(ctx context.Context, arg database.MuteUserParams) error {
+	return mapError(s.q.MuteUser(ctx, MuteUserParams(arg)))
+}
+
+func (s *Store) UnmuteUser(ctx context.Context, arg database.UnmuteUserParams) (int64, error) {
+	return s.q.UnmuteUser(ctx, UnmuteUserParams(arg))
+}
+
+// Audit events
+
+func (s *Store) CreateAuditEvent(ctx context.Context, arg database.CreateAuditEventParams) error {
+	return mapError(s.q.CreateAuditEvent(ctx, CreateAuditEventParams(arg)))
+}
+
+func (s *Store) ListAuditEvents(ctx context.Context, arg database.ListAuditEventsParams) ([]database.AuditEvent, error) {
+	events, err := s.q.ListAuditEvents(ctx, ListAuditEventsParams{
+		ActorID: arg.ActorID,
+		Action:  arg.Action,
+		Since:   arg.Since,
+		Until:   arg.Until,
+		Limit:   int64(arg.Limit),
+		Offset:  int64(arg.Offset),
+	})
+	return convertAll(events, err, toAuditEvent)
+}
+
+// Idempotency keys
+
+func (s *Store) ClaimIdempotencyKey(ctx context.Context, arg database.ClaimIdempotencyKeyParams) (database.IdempotencyKey, error) {
+	key, err := s.q.ClaimIdempotencyKey(ctx, ClaimIdempotencyKeyParams(arg))
+	return database.IdempotencyKey(key), mapError(err)
+}
+
+func (s *Store) GetIdempotencyKey(ctx context.Context, arg database.GetIdempotencyKeyParams) (database.IdempotencyKey, error) {
+	key, err := s.q.GetIdempotencyKey(ctx, GetIdempotencyKeyParams(arg))
+	return database.IdempotencyKey(key), err
+}
+
+func (s *Store) CompleteIdempotencyKey(ctx context.Context, arg database.CompleteIdempotencyKeyParams) error {
+	return s.q.CompleteIdempotencyKey(ctx, CompleteIdempotencyKeyParams(arg))
+}
+
+func (s *Store) DeleteIdempotencyKey(ctx context.Context, arg database.DeleteIdempotencyKeyParams) error {
+	return s.q.DeleteIdempotencyKey(ctx, DeleteIdempotencyKeyParams(arg))
+}
+
+func (s *Store) PurgeIdempotencyKeys(ctx context.Context, expiresAt time.Time) (int64, error) {
+	return s.q.PurgeIdempotencyKeys(ctx, expiresAt)
+}
+
+// Rate limits
+
+func (s *Store) TakeRateLimitToken(ctx context.Context, arg database.TakeRateLimitTokenParams) (database.RateLimitBucket, error) {
+	bucket, err := s.q.TakeRateLimitToken(ctx, TakeRateLimitTokenParams(arg))
+	return database.RateLimitBucket(bucket), mapError(err)
+}
+
+func (s *Store) PurgeRateLimitBuckets(ctx context.Context, updatedAt time.Time) (int64, error) {
+	return s.q.PurgeRateLimitBuckets(ctx, updatedAt)
+}
@@ -0,0 +1,76 @@
+package sqlitedb_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/ihyaulhaq/go-server/internal/database"
+	"github.com/ihyaulhaq/go-server/internal/database/sqlitedb"
+	"github.com/ihyaulhaq/go-server/internal/migrate"
+)
+
+func newStore(t *testing.T) *sqlitedb.Store {
+	t.Helper()
+
+	db, err := sqlitedb.Open(filepath.Join(t.TempDir(), "chirpy.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err := migrate.Up(context.Background(), db, database.DriverSQLite); err != nil {
+		t.Fatal(err)
+	}
+	return sqlitedb.NewStore(db)
+}
+
+func TestStore_ConstraintErrors(t *testing.T) {
+	store := newStore(t)
+	ctx := context.Background()
+
+	user, err := store.CreateUser(ctx, database.CreateUserParams{Email: "a@example.com", HashedPassword: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.ID == uuid.Nil || user.ID.Version() != 4 {
+		t.Fatalf("expected a generated v4 uuid, got %s", user.ID)
+	}
+
+	_, err = store.CreateUser(ctx, database.CreateUserParams{Email: "a@example.com", HashedPassword: "x"})
+	if !errors.Is(err, database.ErrUniqueViolation) {
+		t.Fatalf("expected unique violation, got %v", err)
+	}
+
+	_, err = store.CreateChirps(ctx, database.CreateChirpsParams{Body: "hi", UserID: uuid.New()})
+	if !errors.Is(err, database.ErrForeignKeyViolation) {
+		t.Fatalf("expected foreign key violation, got %v", err)
+	}
+}
+
+func TestStore_DeleteUserCascades(t *testing.T) {
+	store := newStore(t)
+	ctx := context.Background()
+
+	user, err := store.CreateUser(ctx, database.CreateUserParams{Email: "a@example.com", HashedPassword: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.CreateChirps(ctx, database.CreateChirpsParams{Body: "hi", UserID: user.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.DeleteUsers(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	chirps, err := store.GetChirps(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chirps) != 0 {
+		t.Fatalf("expected chirps to cascade, got %d", len(chirps))
+	}
+}
@@ -0,0 +1,19 @@
+package database
+
+import "context"
+
+// Driver names accepted by sql.Open for the supported backends.
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// Store is the storage the API handlers depend on. PostgresStore implements
+// it against Postgres and sqlitedb.Store against SQLite.
+type Store interface {
+	Querier
+
+	// WithTx runs fn against a Store bound to a single transaction,
+	// committing if fn returns nil and rolling back otherwise.
+	WithTx(ctx context.Context, fn func(Store) error) error
+}
@@ -0,0 +1,87 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/ihyaulhaq/go-server/internal/database"
+
+// sqlc prefixes every query with "-- name: <Name> :<kind>".
+var queryNamePattern = regexp.MustCompile(`^-- name: (\w+)`)
+
+// tracedDBTX wraps a DBTX and starts a child span for every call.
+type tracedDBTX struct {
+	db     DBTX
+	system string
+	tracer trace.Tracer
+}
+
+// NewTracedDBTX returns a DBTX that records each query as a span named after
+// its sqlc query, using the global tracer provider. system is reported as
+// db.system.name, e.g. "postgresql" or "sqlite".
+func NewTracedDBTX(db DBTX, system string) DBTX {
+	return &tracedDBTX{
+		db:     db,
+		system: system,
+		tracer: otel.Tracer(tracerName),
+	}
+}
+
+func (t *tracedDBTX) start(ctx context.Context, query string) (context.Context, trace.Span) {
+	name := "unknown"
+	if m := queryNamePattern.FindStringSubmatch(query); m != nil {
+		name = m[1]
+	}
+
+	return t.tracer.Start(ctx, "db."+name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system.name", t.system),
+			attribute.String("db.operation.name", name),
+			attribute.String("db.query.text", query),
+		),
+	)
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func (t *tracedDBTX) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := t.start(ctx, query)
+	result, err := t.db.ExecContext(ctx, query, args...)
+	endSpan(span, err)
+	return result, err
+}
+
+func (t *tracedDBTX) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	ctx, span := t.start(ctx, query)
+	stmt, err := t.db.PrepareContext(ctx, query)
+	endSpan(span, err)
+	return stmt, err
+}
+
+func (t *tracedDBTX) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := t.start(ctx, query)
+	rows, err := t.db.QueryContext(ctx, query, args...)
+	endSpan(span, err)
+	return rows, err
+}
+
+func (t *tracedDBTX) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := t.start(ctx, query)
+	row := t.db.QueryRowContext(ctx, query, args...)
+	endSpan(span, row.Err())
+	return row
+}
@@ -0,0 +1,56 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// RunInTx runs fn inside a transaction on db. The transaction is committed
+// if fn returns nil and rolled back otherwise.
+func RunInTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return errors.Join(err, rollbackErr)
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// PostgresStore is the Postgres Store: the generated queries, traced, plus
+// transactions over the underlying *sql.DB.
+type PostgresStore struct {
+	*Queries
+	db *sql.DB
+	tx *sql.Tx
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{
+		Queries: New(NewTracedDBTX(db, "postgresql")),
+		db:      db,
+	}
+}
+
+// WithTx runs fn against a Store bound to a single transaction. Calls made
+// on a Store that is already in a transaction join it.
+func (s *PostgresStore) WithTx(ctx context.Context, fn func(Store) error) error {
+	if s.tx != nil {
+		return fn(s)
+	}
+
+	return RunInTx(ctx, s.db, func(tx *sql.Tx) error {
+		return fn(&PostgresStore{
+			Queries: New(NewTracedDBTX(tx, "postgresql")),
+			db:      s.db,
+			tx:      tx,
+		})
+	})
+}
@@ -7,11 +7,79 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+const banUser = `-- name: BanUser :one
+WITH revoked AS (
+  UPDATE refresh_tokens
+  SET revoked_at = now(),
+      updated_at = now()
+  WHERE user_id = $1
+    AND revoked_at IS NULL
+)
+UPDATE users
+SET banned_at = now(),
+    ban_reason = $2,
+    updated_at = now()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, suspended_until, suspension_reason, banned_at, ban_reason, deletion_scheduled_at
+`
+
+type BanUserParams struct {
+	ID        uuid.UUID
+	BanReason sql.NullString
+}
+
+func (q *Queries) BanUser(ctx context.Context, arg BanUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, banUser, arg.ID, arg.BanReason)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.BannedAt,
+		&i.BanReason,
+		&i.DeletionScheduledAt,
+	)
+	return i, err
+}
+
+const cancelUserDeletion = `-- name: CancelUserDeletion :one
+UPDATE users
+SET deletion_scheduled_at = NULL,
+    updated_at = now()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, suspended_until, suspension_reason, banned_at, ban_reason, deletion_scheduled_at
+`
+
+func (q *Queries) CancelUserDeletion(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, cancelUserDeletion, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.BannedAt,
+		&i.BanReason,
+		&i.DeletionScheduledAt,
+	)
+	return i, err
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (
   id,
@@ -26,7 +94,7 @@ INSERT INTO users (
   $1,
   $2
 )
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, suspended_until, suspension_reason, banned_at, ban_reason, deletion_scheduled_at
 `
 
 type CreateUserParams struct {
@@ -44,6 +112,11 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.BannedAt,
+		&i.BanReason,
+		&i.DeletionScheduledAt,
 	)
 	return i, err
 }
@@ -58,7 +131,7 @@ func (q *Queries) DeleteUsers(ctx context.Context) error {
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red FROM users WHERE email = $1
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, suspended_until, suspension_reason, banned_at, ban_reason, deletion_scheduled_at FROM users WHERE email = $1
 `
 
 func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
@@ -71,6 +144,229 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.BannedAt,
+		&i.BanReason,
+		&i.DeletionScheduledAt,
+	)
+	return i, err
+}
+
+const getUserByID = `-- name: GetUserByID :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, suspended_until, suspension_reason, banned_at, ban_reason, deletion_scheduled_at FROM users WHERE id = $1
+`
+
+func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByID, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.BannedAt,
+		&i.BanReason,
+		&i.DeletionScheduledAt,
+	)
+	return i, err
+}
+
+const insertUser = `-- name: InsertUser :one
+INSERT INTO users (
+  id,
+  created_at,
+  updated_at,
+  email,
+  hashed_password,
+  is_chirpy_red
+) VALUES (
+  $1,
+  $2,
+  $2,
+  $3,
+  $4,
+  $5
+)
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, suspended_until, suspension_reason, banned_at, ban_reason, deletion_scheduled_at
+`
+
+type InsertUserParams struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	Email          string
+	HashedPassword string
+	IsChirpyRed    bool
+}
+
+func (q *Queries) InsertUser(ctx context.Context, arg InsertUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, insertUser, arg.ID, arg.CreatedAt, arg.Email, arg.HashedPassword, arg.IsChirpyRed)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.BannedAt,
+		&i.BanReason,
+		&i.DeletionScheduledAt,
+	)
+	return i, err
+}
+
+const purgeScheduledUserDeletions = `-- name: PurgeScheduledUserDeletions :execrows
+DELETE FROM users
+WHERE deletion_scheduled_at < $1
+`
+
+func (q *Queries) PurgeScheduledUserDeletions(ctx context.Context, deletionScheduledAt sql.NullTime) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeScheduledUserDeletions, deletionScheduledAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const scheduleUserDeletion = `-- name: ScheduleUserDeletion :one
+WITH revoked AS (
+  UPDATE refresh_tokens
+  SET revoked_at = now(),
+      updated_at = now()
+  WHERE user_id = $1
+    AND revoked_at IS NULL
+)
+UPDATE users
+SET deletion_scheduled_at = $2,
+    updated_at = now()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, suspended_until, suspension_reason, banned_at, ban_reason, deletion_scheduled_at
+`
+
+type ScheduleUserDeletionParams struct {
+	ID                  uuid.UUID
+	DeletionScheduledAt sql.NullTime
+}
+
+func (q *Queries) ScheduleUserDeletion(ctx context.Context, arg ScheduleUserDeletionParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, scheduleUserDeletion, arg.ID, arg.DeletionScheduledAt)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.BannedAt,
+		&i.BanReason,
+		&i.DeletionScheduledAt,
+	)
+	return i, err
+}
+
+const suspendUser = `-- name: SuspendUser :one
+WITH revoked AS (
+  UPDATE refresh_tokens
+  SET revoked_at = now(),
+      updated_at = now()
+  WHERE user_id = $1
+    AND revoked_at IS NULL
+)
+UPDATE users
+SET suspended_until = $2,
+    suspension_reason = $3,
+    updated_at = now()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, suspended_until, suspension_reason, banned_at, ban_reason, deletion_scheduled_at
+`
+
+type SuspendUserParams struct {
+	ID               uuid.UUID
+	SuspendedUntil   sql.NullTime
+	SuspensionReason sql.NullString
+}
+
+func (q *Queries) SuspendUser(ctx context.Context, arg SuspendUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, suspendUser, arg.ID, arg.SuspendedUntil, arg.SuspensionReason)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.BannedAt,
+		&i.BanReason,
+		&i.DeletionScheduledAt,
+	)
+	return i, err
+}
+
+const unbanUser = `-- name: UnbanUser :one
+UPDATE users
+SET banned_at = NULL,
+    ban_reason = NULL,
+    updated_at = now()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, suspended_until, suspension_reason, banned_at, ban_reason, deletion_scheduled_at
+`
+
+func (q *Queries) UnbanUser(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, unbanUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.BannedAt,
+		&i.BanReason,
+		&i.DeletionScheduledAt,
+	)
+	return i, err
+}
+
+const unsuspendUser = `-- name: UnsuspendUser :one
+UPDATE users
+SET suspended_until = NULL,
+    suspension_reason = NULL,
+    updated_at = now()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, suspended_until, suspension_reason, banned_at, ban_reason, deletion_scheduled_at
+`
+
+func (q *Queries) UnsuspendUser(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, unsuspendUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.BannedAt,
+		&i.BanReason,
+		&i.DeletionScheduledAt,
 	)
 	return i, err
 }
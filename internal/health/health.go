@@ -0,0 +1,124 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// Failed components report one of these instead of the checker's error,
+// which can name hosts, SQL states or schema versions.
+const (
+	ErrorUnavailable = "unavailable"
+	ErrorTimeout     = "timeout"
+)
+
+// Checker is a single dependency check reported as one readiness component.
+type Checker interface {
+	Name() string
+	Check(ctx context.Context) error
+}
+
+type checkFunc struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+func (c checkFunc) Name() string                    { return c.name }
+func (c checkFunc) Check(ctx context.Context) error { return c.fn(ctx) }
+
+// NewChecker adapts a plain function into a Checker.
+func NewChecker(name string, fn func(ctx context.Context) error) Checker {
+	return checkFunc{name: name, fn: fn}
+}
+
+type ComponentStatus struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentStatus `json:"components"`
+}
+
+// Run executes every checker concurrently, each bounded by timeout, and
+// reports StatusOK only if all of them pass.
+func Run(ctx context.Context, timeout time.Duration, checkers []Checker) Report {
+	report := Report{
+		Status:     StatusOK,
+		Components: make(map[string]ComponentStatus, len(checkers)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, checker := range checkers {
+		wg.Add(1)
+		go func(checker Checker) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := checker.Check(checkCtx)
+			component := ComponentStatus{
+				Status:    StatusOK,
+				LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				slog.Error("readiness check failed", "component", checker.Name(), "error", err)
+				component.Status = StatusFail
+				component.Error = ErrorUnavailable
+				if errors.Is(err, context.DeadlineExceeded) {
+					component.Error = ErrorTimeout
+				}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Components[checker.Name()] = component
+			if err != nil {
+				report.Status = StatusFail
+			}
+		}(checker)
+	}
+
+	wg.Wait()
+	return report
+}
+
+// DatabaseChecker pings the database.
+func DatabaseChecker(db *sql.DB) Checker {
+	return NewChecker("database", db.PingContext)
+}
+
+// MigrationChecker verifies that goose has applied exactly the expected
+// schema version.
+func MigrationChecker(db *sql.DB, expected int64) Checker {
+	return NewChecker("migrations", func(ctx context.Context) error {
+		var version sql.NullInt64
+		err := db.QueryRowContext(ctx,
+			"SELECT MAX(version_id) FROM goose_db_version WHERE is_applied",
+		).Scan(&version)
+		if err != nil {
+			return fmt.Errorf("reading schema version: %w", err)
+		}
+
+		if version.Int64 != expected {
+			return fmt.Errorf("schema version %d, expected %d", version.Int64, expected)
+		}
+		return nil
+	})
+}
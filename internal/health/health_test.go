@@ -0,0 +1,60 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRun_AllHealthy(t *testing.T) {
+	report := Run(context.Background(), time.Second, []Checker{
+		NewChecker("a", func(ctx context.Context) error { return nil }),
+		NewChecker("b", func(ctx context.Context) error { return nil }),
+	})
+
+	if report.Status != StatusOK {
+		t.Fatalf("expected ok, got %s", report.Status)
+	}
+
+	if len(report.Components) != 2 {
+		t.Fatalf("expected 2 components, got %d", len(report.Components))
+	}
+}
+
+func TestRun_FailingChecker(t *testing.T) {
+	report := Run(context.Background(), time.Second, []Checker{
+		NewChecker("ok", func(ctx context.Context) error { return nil }),
+		NewChecker("broken", func(ctx context.Context) error { return errors.New("boom") }),
+	})
+
+	if report.Status != StatusFail {
+		t.Fatalf("expected fail, got %s", report.Status)
+	}
+
+	broken := report.Components["broken"]
+	if broken.Status != StatusFail || broken.Error != ErrorUnavailable {
+		t.Fatalf("unexpected component %+v", broken)
+	}
+
+	if report.Components["ok"].Status != StatusOK {
+		t.Fatal("expected healthy component to stay ok")
+	}
+}
+
+func TestRun_Timeout(t *testing.T) {
+	report := Run(context.Background(), 10*time.Millisecond, []Checker{
+		NewChecker("slow", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}),
+	})
+
+	if report.Status != StatusFail {
+		t.Fatalf("expected fail, got %s", report.Status)
+	}
+
+	if report.Components["slow"].Error != ErrorTimeout {
+		t.Fatal("expected timeout error")
+	}
+}
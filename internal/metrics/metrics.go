@@ -0,0 +1,159 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "chirpy"
+
+// Metrics owns the Prometheus registry and every collector the server
+// exports. All methods are safe to call on a nil *Metrics.
+type Metrics struct {
+	Registry *prometheus.Registry
+
+	RequestsTotal   *prometheus.CounterVec
+	RequestDuration *prometheus.HistogramVec
+	InFlight        prometheus.Gauge
+
+	FileserverHits prometheus.Counter
+	ChirpsCreated  prometheus.Counter
+	Logins         *prometheus.CounterVec
+}
+
+// New registers the HTTP, business and runtime collectors. db may be nil,
+// in which case connection pool stats are not exported.
+func New(db *sql.DB) *Metrics {
+	m := &Metrics{
+		Registry: prometheus.NewRegistry(),
+		RequestsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by route pattern, method and status.",
+		}, []string{"route", "method", "status"}),
+		RequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by route pattern, method and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+		InFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "http_requests_in_flight",
+			Help:      "HTTP requests currently being served.",
+		}),
+		FileserverHits: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "fileserver_hits_total",
+			Help:      "Requests served by the /app/ file server.",
+		}),
+		ChirpsCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "chirps_created_total",
+			Help:      "Chirps created.",
+		}),
+		Logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "logins_total",
+			Help:      "Login attempts by result.",
+		}, []string{"result"}),
+	}
+
+	m.Registry.MustRegister(
+		m.RequestsTotal,
+		m.RequestDuration,
+		m.InFlight,
+		m.FileserverHits,
+		m.ChirpsCreated,
+		m.Logins,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	if db != nil {
+		m.Registry.MustRegister(collectors.NewDBStatsCollector(db, namespace))
+	}
+
+	// Pre-create the login series so both show up as zero before any login.
+	m.Logins.WithLabelValues("success")
+	m.Logins.WithLabelValues("failure")
+
+	return m
+}
+
+// Handler serves the registry in the Prometheus text exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.Registry, promhttp.HandlerOpts{})
+}
+
+func (m *Metrics) IncFileserverHits() {
+	if m == nil {
+		return
+	}
+	m.FileserverHits.Inc()
+}
+
+func (m *Metrics) IncChirpsCreated() {
+	if m == nil {
+		return
+	}
+	m.ChirpsCreated.Inc()
+}
+
+func (m *Metrics) ObserveLogin(success bool) {
+	if m == nil {
+		return
+	}
+	result := "failure"
+	if success {
+		result = "success"
+	}
+	m.Logins.WithLabelValues(result).Inc()
+}
+
+// CounterValue returns the current value of the named counter, summed across
+// all of its label values. It returns 0 if the metric does not exist.
+func (m *Metrics) CounterValue(name string) float64 {
+	return m.sumCounter(name, func(map[string]string) bool { return true })
+}
+
+// LabeledCounterValue is CounterValue restricted to the series whose label
+// has the given value.
+func (m *Metrics) LabeledCounterValue(name, label, value string) float64 {
+	return m.sumCounter(name, func(labels map[string]string) bool { return labels[label] == value })
+}
+
+func (m *Metrics) sumCounter(name string, match func(labels map[string]string) bool) float64 {
+	if m == nil {
+		return 0
+	}
+
+	families, err := m.Registry.Gather()
+	if err != nil {
+		return 0
+	}
+
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+
+		var total float64
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, pair := range metric.GetLabel() {
+				labels[pair.GetName()] = pair.GetValue()
+			}
+			if match(labels) {
+				total += metric.GetCounter().GetValue()
+			}
+		}
+		return total
+	}
+
+	return 0
+}
@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCounterValue(t *testing.T) {
+	m := New(nil)
+
+	m.IncFileserverHits()
+	m.IncFileserverHits()
+	m.ObserveLogin(true)
+	m.ObserveLogin(false)
+	m.ObserveLogin(false)
+
+	if got := m.CounterValue("chirpy_fileserver_hits_total"); got != 2 {
+		t.Fatalf("expected 2 fileserver hits, got %v", got)
+	}
+
+	if got := m.CounterValue("chirpy_logins_total"); got != 3 {
+		t.Fatalf("expected 3 logins, got %v", got)
+	}
+
+	if got := m.LabeledCounterValue("chirpy_logins_total", "result", "failure"); got != 2 {
+		t.Fatalf("expected 2 failed logins, got %v", got)
+	}
+
+	if got := m.CounterValue("does_not_exist"); got != 0 {
+		t.Fatalf("expected 0 for unknown metric, got %v", got)
+	}
+}
+
+func TestNilMetricsIsSafe(t *testing.T) {
+	var m *Metrics
+
+	m.IncFileserverHits()
+	m.IncChirpsCreated()
+	m.ObserveLogin(true)
+
+	if got := m.CounterValue("chirpy_fileserver_hits_total"); got != 0 {
+		t.Fatalf("expected 0, got %v", got)
+	}
+}
+
+func TestHandlerExposition(t *testing.T) {
+	m := New(nil)
+	m.IncChirpsCreated()
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	body, _ := io.ReadAll(rec.Body)
+	if !strings.Contains(string(body), "chirpy_chirps_created_total 1") {
+		t.Fatalf("expected chirps counter in output, got:\n%s", body)
+	}
+
+	if !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain") {
+		t.Fatalf("unexpected content type %q", rec.Header().Get("Content-Type"))
+	}
+}
@@ -0,0 +1,153 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+
+	"github.com/ihyaulhaq/go-server/internal/database"
+	"github.com/ihyaulhaq/go-server/sql/schema"
+	sqliteschema "github.com/ihyaulhaq/go-server/sql/sqlite/schema"
+)
+
+// ErrSchemaAhead is returned when the database has migrations applied that
+// this binary does not know about.
+var ErrSchemaAhead = errors.New("database schema is ahead of this binary")
+
+// Commands lists the subcommands accepted by Run.
+var Commands = []string{"up", "down", "status", "redo"}
+
+// migrations returns the embedded migrations for a database driver. Each
+// backend has its own schema history.
+func migrations(driver string) fs.FS {
+	if driver == database.DriverSQLite {
+		return sqliteschema.FS
+	}
+	return schema.FS
+}
+
+// NewProvider returns a goose provider over the embedded migrations for
+// driver. On Postgres, up and down runs hold an advisory lock so that
+// concurrent replicas migrate one at a time; SQLite is single-node and
+// serializes writers itself.
+func NewProvider(db *sql.DB, driver string) (*goose.Provider, error) {
+	if driver == database.DriverSQLite {
+		return goose.NewProvider(goose.DialectSQLite3, db, migrations(driver))
+	}
+
+	locker, err := lock.NewPostgresSessionLocker()
+	if err != nil {
+		return nil, err
+	}
+
+	return goose.NewProvider(goose.DialectPostgres, db, migrations(driver),
+		goose.WithSessionLocker(locker),
+	)
+}
+
+// LatestVersion is the highest migration version embedded in the binary for
+// driver.
+func LatestVersion(driver string) int64 {
+	names, err := fs.Glob(migrations(driver), "*.sql")
+	if err != nil {
+		return 0
+	}
+
+	var latest int64
+	for _, name := range names {
+		version, err := goose.NumericComponent(path.Base(name))
+		if err != nil {
+			continue
+		}
+		latest = max(latest, version)
+	}
+	return latest
+}
+
+// Run executes one of Commands and writes a human-readable summary to out.
+func Run(ctx context.Context, db *sql.DB, driver, command string, out io.Writer) error {
+	provider, err := NewProvider(db, driver)
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "up":
+		results, err := provider.Up(ctx)
+		printResults(out, results...)
+		return err
+	case "down":
+		result, err := provider.Down(ctx)
+		printResults(out, result)
+		return err
+	case "redo":
+		result, err := provider.Down(ctx)
+		printResults(out, result)
+		if err != nil {
+			return err
+		}
+		result, err = provider.UpByOne(ctx)
+		printResults(out, result)
+		return err
+	case "status":
+		statuses, err := provider.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			applied := "pending"
+			if s.State == goose.StateApplied {
+				applied = s.AppliedAt.UTC().Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(out, "%-24s %s\n", applied, path.Base(s.Source.Path))
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate command %q", command)
+	}
+}
+
+func printResults(out io.Writer, results ...*goose.MigrationResult) {
+	for _, r := range results {
+		if r != nil {
+			fmt.Fprintln(out, r.String())
+		}
+	}
+}
+
+// Up applies all pending migrations, under the advisory lock on Postgres.
+func Up(ctx context.Context, db *sql.DB, driver string) error {
+	provider, err := NewProvider(db, driver)
+	if err != nil {
+		return err
+	}
+
+	_, err = provider.Up(ctx)
+	return err
+}
+
+// CheckCompatible returns ErrSchemaAhead if the database has been migrated
+// past LatestVersion.
+func CheckCompatible(ctx context.Context, db *sql.DB, driver string) error {
+	provider, err := NewProvider(db, driver)
+	if err != nil {
+		return err
+	}
+
+	current, err := provider.GetDBVersion(ctx)
+	if err != nil {
+		return fmt.Errorf("reading schema version: %w", err)
+	}
+
+	if latest := LatestVersion(driver); current > latest {
+		return fmt.Errorf("%w: database is at version %d, binary knows up to %d", ErrSchemaAhead, current, latest)
+	}
+	return nil
+}
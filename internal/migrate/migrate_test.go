@@ -0,0 +1,59 @@
+package migrate
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"testing"
+
+	"github.com/ihyaulhaq/go-server/internal/database"
+	"github.com/ihyaulhaq/go-server/internal/database/sqlitedb"
+)
+
+func TestLatestVersion(t *testing.T) {
+	for _, driver := range []string{database.DriverPostgres, database.DriverSQLite} {
+		t.Run(driver, func(t *testing.T) {
+			names, err := fs.Glob(migrations(driver), "*.sql")
+			if err != nil {
+				t.Fatalf("glob error: %v", err)
+			}
+
+			if len(names) == 0 {
+				t.Fatal("expected embedded migrations")
+			}
+
+			// Migrations are numbered without gaps, so the latest version is the count.
+			if got := LatestVersion(driver); got != int64(len(names)) {
+				t.Fatalf("expected latest version %d, got %d", len(names), got)
+			}
+		})
+	}
+}
+
+func TestUpDown_SQLite(t *testing.T) {
+	db, err := sqlitedb.Open(filepath.Join(t.TempDir(), "chirpy.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	ctx := context.Background()
+	if err := Up(ctx, db, database.DriverSQLite); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+
+	if err := CheckCompatible(ctx, db, database.DriverSQLite); err != nil {
+		t.Fatalf("expected compatible schema, got %v", err)
+	}
+
+	if err := Run(ctx, db, database.DriverSQLite, "redo", io.Discard); err != nil {
+		t.Fatalf("redo: %v", err)
+	}
+
+	var tables int
+	err = db.QueryRowContext(ctx, "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'chirps'").Scan(&tables)
+	if err != nil || tables != 1 {
+		t.Fatalf("expected chirps table after redo, got %d (%v)", tables, err)
+	}
+}
@@ -0,0 +1,124 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/ihyaulhaq/go-server/internal/database"
+	"github.com/lib/pq"
+)
+
+// notifyChannel is the Postgres channel chirp events are sent on.
+const notifyChannel = "chirp_events"
+
+// listenerPingInterval is how often an idle listener checks its
+// connection, so a dead one is noticed and reconnected.
+const listenerPingInterval = 90 * time.Second
+
+// Bounds of the backoff between attempts to connect and LISTEN.
+const (
+	listenerMinBackoff = time.Second
+	listenerMaxBackoff = time.Minute
+)
+
+// PGNotifier publishes events with NOTIFY so every replica LISTENing on
+// the same database receives them, the publishing one included.
+type PGNotifier struct {
+	// DB runs the NOTIFY; pass a database.NewTracedDBTX so it is traced
+	// like other queries.
+	DB database.DBTX
+	// DSN is the connection URL for the dedicated LISTEN connection.
+	DSN string
+
+	listening atomic.Bool
+}
+
+func (n *PGNotifier) Publish(ctx context.Context, event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = n.DB.ExecContext(ctx, "SELECT pg_notify($1, $2)", notifyChannel, string(payload))
+	return err
+}
+
+// Ready fails while the LISTEN connection is down, since streams on this
+// replica would silently miss events.
+func (n *PGNotifier) Ready(ctx context.Context) error {
+	if !n.listening.Load() {
+		return errors.New("not listening for chirp events")
+	}
+	return nil
+}
+
+// Listen feeds notifications into hub until ctx is done, retrying with
+// backoff when LISTEN fails. Events sent while the connection is down are
+// lost; subscribers catch up only on what this replica's hub has seen.
+func (n *PGNotifier) Listen(ctx context.Context, hub *Hub) {
+	backoff := listenerMinBackoff
+	for {
+		err := n.listen(ctx, hub)
+		if ctx.Err() != nil {
+			return
+		}
+		slog.Error("error listening for chirp events", "error", err, "retry_in", backoff)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, listenerMaxBackoff)
+	}
+}
+
+func (n *PGNotifier) listen(ctx context.Context, hub *Hub) error {
+	listener := pq.NewListener(n.DSN, listenerMinBackoff, listenerMaxBackoff, func(ev pq.ListenerEventType, err error) {
+		switch ev {
+		case pq.ListenerEventReconnected:
+			n.listening.Store(true)
+		case pq.ListenerEventDisconnected, pq.ListenerEventConnectionAttemptFailed:
+			n.listening.Store(false)
+		}
+		if err != nil {
+			slog.Error("chirp event listener connection error", "error", err)
+		}
+	})
+	defer listener.Close()
+	defer n.listening.Store(false)
+
+	if err := listener.Listen(notifyChannel); err != nil {
+		return fmt.Errorf("listening for chirp events: %w", err)
+	}
+	n.listening.Store(true)
+
+	ping := time.NewTicker(listenerPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ping.C:
+			go listener.Ping()
+		case notification := <-listener.Notify:
+			// A nil notification means the connection was re-established.
+			if notification == nil {
+				slog.Warn("chirp event listener reconnected; events may have been missed")
+				continue
+			}
+
+			var event Event
+			if err := json.Unmarshal([]byte(notification.Extra), &event); err != nil {
+				slog.Error("error decoding chirp event", "error", err)
+				continue
+			}
+			hub.Publish(ctx, event)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+// Package pubsub fans chirp changes out to live subscribers, such as the
+// clients of GET /api/chirps/stream.
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	TypeChirpCreated = "chirp_created"
+	TypeChirpDeleted = "chirp_deleted"
+)
+
+// subscriberBuffer is how many events a subscriber may fall behind before
+// it is dropped.
+const subscriberBuffer = 64
+
+// Event is a single change. Data is the JSON sent to subscribers as is.
+type Event struct {
+	ID       string          `json:"id"`
+	Type     string          `json:"type"`
+	AuthorID uuid.UUID       `json:"author_id"`
+	Data     json.RawMessage `json:"data"`
+}
+
+// NewID returns an event ID for a change to key at the given time. IDs
+// are created by the replica that publishes the event, so they are the
+// same on every replica and can be compared by time when resuming.
+func NewID(at time.Time, key string) string {
+	return strconv.FormatInt(at.UnixNano(), 10) + "-" + key
+}
+
+func idTime(id string) (int64, bool) {
+	prefix, _, _ := strings.Cut(id, "-")
+	nanos, err := strconv.ParseInt(prefix, 10, 64)
+	return nanos, err == nil
+}
+
+// Publisher sends an event to the hubs of every replica.
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// Hub delivers events to the subscribers in this process and keeps the
+// most recent ones so reconnecting subscribers can catch up.
+type Hub struct {
+	mu      sync.Mutex
+	subs    map[*Subscription]struct{}
+	recent  []Event
+	backlog int
+	closed  bool
+}
+
+// NewHub returns a hub that remembers the last backlog events.
+func NewHub(backlog int) *Hub {
+	return &Hub{subs: map[*Subscription]struct{}{}, backlog: backlog}
+}
+
+// Subscription receives events on C until it is closed, the hub is closed,
+// or it falls too far behind, at which point C is closed.
+type Subscription struct {
+	C   <-chan Event
+	ch  chan Event
+	hub *Hub
+}
+
+// Publish delivers event to this hub only, so it is the Publisher when
+// there is a single replica.
+func (h *Hub) Publish(_ context.Context, event Event) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.recent = append(h.recent, event)
+	if len(h.recent) > h.backlog {
+		h.recent = h.recent[len(h.recent)-h.backlog:]
+	}
+
+	for sub := range h.subs {
+		select {
+		case sub.ch <- event:
+		default:
+			// Don't let one slow client hold up the rest; it can
+			// reconnect and resume from the backlog.
+			h.remove(sub)
+		}
+	}
+	return nil
+}
+
+// Subscribe registers a subscriber. When lastEventID is set, the
+// remembered events after it are returned to be sent first; no event is
+// both returned and delivered on C.
+func (h *Hub) Subscribe(lastEventID string) (*Subscription, []Event) {
+	ch := make(chan Event, subscriberBuffer)
+	sub := &Subscription{C: ch, ch: ch, hub: h}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		close(ch)
+		return sub, nil
+	}
+	h.subs[sub] = struct{}{}
+	return sub, h.since(lastEventID)
+}
+
+// since returns the remembered events after id. An id that has already
+// left the backlog, or that arrived here in a different order than on the
+// replica that served it, falls back to comparing event times.
+func (h *Hub) since(id string) []Event {
+	if id == "" {
+		return nil
+	}
+
+	for i, event := range h.recent {
+		if event.ID == id {
+			return append([]Event(nil), h.recent[i+1:]...)
+		}
+	}
+
+	after, ok := idTime(id)
+	if !ok {
+		return nil
+	}
+	var events []Event
+	for _, event := range h.recent {
+		if t, ok := idTime(event.ID); ok && t > after {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// remove unregisters sub and closes its channel. The caller holds h.mu.
+func (h *Hub) remove(sub *Subscription) {
+	if _, ok := h.subs[sub]; ok {
+		delete(h.subs, sub)
+		close(sub.ch)
+	}
+}
+
+// Close stops delivering events to sub.
+func (sub *Subscription) Close() {
+	sub.hub.mu.Lock()
+	defer sub.hub.mu.Unlock()
+	sub.hub.remove(sub)
+}
+
+// Close ends every subscription, so long-lived streams finish and the
+// server can shut down.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for sub := range h.subs {
+		h.remove(sub)
+	}
+}
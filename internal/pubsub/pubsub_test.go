@@ -0,0 +1,111 @@
+package pubsub
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var base = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func testEvent(i int) Event {
+	return Event{
+		ID:       NewID(base.Add(time.Duration(i)*time.Second), fmt.Sprint(i)),
+		Type:     TypeChirpCreated,
+		AuthorID: uuid.New(),
+		Data:     []byte(`{}`),
+	}
+}
+
+func ids(events []Event) []string {
+	out := make([]string, len(events))
+	for i, event := range events {
+		out[i] = event.ID
+	}
+	return out
+}
+
+func TestHub_Deliver(t *testing.T) {
+	hub := NewHub(10)
+	a, _ := hub.Subscribe("")
+	b, _ := hub.Subscribe("")
+	b.Close()
+
+	hub.Publish(context.Background(), testEvent(1))
+
+	select {
+	case event := <-a.C:
+		if event.ID != testEvent(1).ID {
+			t.Fatalf("unexpected event %v", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the event to be delivered")
+	}
+	if _, ok := <-b.C; ok {
+		t.Fatal("expected a closed subscription to receive nothing")
+	}
+
+	hub.Close()
+	if _, ok := <-a.C; ok {
+		t.Fatal("expected Close to end subscriptions")
+	}
+	late, _ := hub.Subscribe("")
+	if _, ok := <-late.C; ok {
+		t.Fatal("expected subscriptions after Close to be closed")
+	}
+}
+
+func TestHub_Resume(t *testing.T) {
+	hub := NewHub(3)
+	for i := 1; i <= 5; i++ {
+		hub.Publish(context.Background(), testEvent(i))
+	}
+
+	tests := []struct {
+		name   string
+		lastID string
+		want   []string
+	}{
+		{"fresh", "", []string{}},
+		{"in backlog", testEvent(3).ID, ids([]Event{testEvent(4), testEvent(5)})},
+		{"latest", testEvent(5).ID, []string{}},
+		// Evicted IDs, or IDs from events this replica never saw, are
+		// resumed by time.
+		{"evicted", testEvent(1).ID, ids([]Event{testEvent(3), testEvent(4), testEvent(5)})},
+		{"unknown", NewID(base.Add(3500*time.Millisecond), "other"), ids([]Event{testEvent(4), testEvent(5)})},
+		{"malformed", "garbage", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, replay := hub.Subscribe(tt.lastID)
+			defer sub.Close()
+			if got := ids(replay); !slices.Equal(got, tt.want) {
+				t.Fatalf("expected replay %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestHub_DropsSlowSubscriber(t *testing.T) {
+	hub := NewHub(10)
+	slow, _ := hub.Subscribe("")
+
+	for i := 0; i <= subscriberBuffer; i++ {
+		hub.Publish(context.Background(), testEvent(i))
+	}
+
+	received := 0
+	for range slow.C {
+		received++
+	}
+	if received != subscriberBuffer {
+		t.Fatalf("expected %d buffered events before the drop, got %d", subscriberBuffer, received)
+	}
+	// Closing after the hub dropped it must be harmless.
+	slow.Close()
+}
@@ -0,0 +1,131 @@
+// Package ratelimit implements token-bucket rate limiting. A bucket holds
+// up to Policy.Limit tokens and refills continuously at Limit per Period;
+// each request takes one token and is rejected when none is left.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ihyaulhaq/go-server/internal/database"
+)
+
+type Policy struct {
+	// Limit is the bucket size, i.e. the largest burst allowed.
+	Limit int
+	// Period is how long an empty bucket takes to refill completely.
+	Period time.Duration
+}
+
+// rate is the refill rate in tokens per second.
+func (p Policy) rate() float64 {
+	return float64(p.Limit) / p.Period.Seconds()
+}
+
+func (p Policy) String() string {
+	return fmt.Sprintf("%d/%s", p.Limit, p.Period)
+}
+
+// Result is the outcome of taking a token, with enough detail to fill in
+// RateLimit-* and Retry-After response headers.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// RetryAfter is how long until a token is available. Zero when Allowed.
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+}
+
+// Store keeps token buckets. Implementations must make Take atomic per key.
+type Store interface {
+	Take(ctx context.Context, key string, policy Policy) (Result, error)
+}
+
+// newResult describes a bucket left with tokens after a take.
+func newResult(policy Policy, tokens float64, allowed bool) Result {
+	rate := policy.rate()
+	res := Result{
+		Allowed:   allowed,
+		Limit:     policy.Limit,
+		Remaining: max(0, int(math.Floor(tokens))),
+		Reset:     secondsToDuration((float64(policy.Limit) - tokens) / rate),
+	}
+	if !allowed {
+		res.RetryAfter = secondsToDuration((1 - tokens) / rate)
+	}
+	return res
+}
+
+func secondsToDuration(s float64) time.Duration {
+	return time.Duration(math.Ceil(max(0, s) * float64(time.Second)))
+}
+
+// DBStore keeps buckets in the database so that every replica sharing it
+// enforces the same limits.
+type DBStore struct {
+	DB database.Querier
+}
+
+func (s DBStore) Take(ctx context.Context, key string, policy Policy) (Result, error) {
+	bucket, err := s.DB.TakeRateLimitToken(ctx, database.TakeRateLimitTokenParams{
+		Key:        key,
+		Capacity:   float64(policy.Limit),
+		RefillRate: policy.rate(),
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	return newResult(policy, bucket.Tokens, bucket.Allowed), nil
+}
+
+// ParsePolicies parses a comma-separated list of route policies such as
+//
+//	POST /api/chirps=30/1m,POST /api/login=10/1m
+//
+// Each key is a route pattern exactly as registered on the mux, and each
+// value allows that many requests per period, in bursts of up to the same
+// number.
+func ParsePolicies(spec string) (map[string]Policy, error) {
+	policies := map[string]Policy{}
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		pattern, value, ok := strings.Cut(entry, "=")
+		pattern = strings.TrimSpace(pattern)
+		if !ok || pattern == "" {
+			return nil, fmt.Errorf("rate limit %q: want <route>=<requests>/<period>", entry)
+		}
+
+		limitStr, periodStr, ok := strings.Cut(strings.TrimSpace(value), "/")
+		if !ok {
+			return nil, fmt.Errorf("rate limit %q: want <route>=<requests>/<period>", entry)
+		}
+
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			return nil, fmt.Errorf("rate limit %q: requests must be a positive integer", entry)
+		}
+
+		period, err := time.ParseDuration(periodStr)
+		if err != nil || period <= 0 {
+			return nil, fmt.Errorf("rate limit %q: period must be a positive duration", entry)
+		}
+
+		if _, dup := policies[pattern]; dup {
+			return nil, fmt.Errorf("rate limit for %q given more than once", pattern)
+		}
+		policies[pattern] = Policy{Limit: limit, Period: period}
+	}
+
+	return policies, nil
+}
@@ -0,0 +1,151 @@
+package ratelimit
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ihyaulhaq/go-server/internal/database"
+	"github.com/ihyaulhaq/go-server/internal/database/memstore"
+	"github.com/ihyaulhaq/go-server/internal/database/sqlitedb"
+	"github.com/ihyaulhaq/go-server/internal/migrate"
+)
+
+func TestParsePolicies(t *testing.T) {
+	policies, err := ParsePolicies(" POST /api/chirps=30/1m , POST /api/login=5/10s,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(policies) != 2 {
+		t.Fatalf("expected 2 policies, got %v", policies)
+	}
+	if got := policies["POST /api/chirps"]; got != (Policy{Limit: 30, Period: time.Minute}) {
+		t.Fatalf("unexpected chirps policy %v", got)
+	}
+	if got := policies["POST /api/login"]; got != (Policy{Limit: 5, Period: 10 * time.Second}) {
+		t.Fatalf("unexpected login policy %v", got)
+	}
+
+	if policies, err := ParsePolicies(""); err != nil || len(policies) != 0 {
+		t.Fatalf("expected no policies, got %v, %v", policies, err)
+	}
+
+	for _, spec := range []string{
+		"POST /api/chirps",
+		"=5/1m",
+		"POST /api/chirps=5",
+		"POST /api/chirps=0/1m",
+		"POST /api/chirps=x/1m",
+		"POST /api/chirps=5/0s",
+		"POST /api/chirps=5/soon",
+		"POST /api/chirps=5/1m,POST /api/chirps=6/1m",
+	} {
+		if _, err := ParsePolicies(spec); err == nil {
+			t.Errorf("ParsePolicies(%q): expected error", spec)
+		}
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore()
+	clock := time.Unix(1_700_000_000, 0)
+	store.now = func() time.Time { return clock }
+
+	ctx := context.Background()
+	policy := Policy{Limit: 3, Period: 3 * time.Second}
+
+	for i := range 3 {
+		res, err := store.Take(ctx, "k", policy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !res.Allowed || res.Remaining != 2-i {
+			t.Fatalf("take %d: unexpected result %+v", i, res)
+		}
+	}
+
+	res, _ := store.Take(ctx, "k", policy)
+	if res.Allowed || res.Remaining != 0 || res.RetryAfter != time.Second || res.Reset != 3*time.Second {
+		t.Fatalf("expected to be limited for 1s, got %+v", res)
+	}
+
+	if res, _ := store.Take(ctx, "other", policy); !res.Allowed {
+		t.Fatal("keys should have separate buckets")
+	}
+
+	clock = clock.Add(time.Second)
+	if res, _ := store.Take(ctx, "k", policy); !res.Allowed || res.Remaining != 0 {
+		t.Fatalf("expected one refilled token, got %+v", res)
+	}
+
+	clock = clock.Add(time.Hour)
+	if res, _ := store.Take(ctx, "k", policy); !res.Allowed || res.Remaining != 2 {
+		t.Fatalf("expected a full bucket, got %+v", res)
+	}
+	if len(store.buckets) != 1 {
+		t.Fatalf("expected refilled buckets to be swept, have %d", len(store.buckets))
+	}
+}
+
+func TestDBStore(t *testing.T) {
+	stores := map[string]func(t *testing.T) database.Store{
+		"memory": func(t *testing.T) database.Store { return memstore.New() },
+		"sqlite": func(t *testing.T) database.Store {
+			db, err := sqlitedb.Open(filepath.Join(t.TempDir(), "chirpy.db"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { db.Close() })
+
+			if err := migrate.Up(context.Background(), db, database.DriverSQLite); err != nil {
+				t.Fatal(err)
+			}
+			return sqlitedb.NewStore(db)
+		},
+	}
+
+	for name, open := range stores {
+		t.Run(name, func(t *testing.T) {
+			db := open(t)
+			store := DBStore{DB: db}
+			ctx := context.Background()
+
+			// A long period keeps refill negligible for the test's duration.
+			policy := Policy{Limit: 2, Period: time.Hour}
+
+			for i := range 2 {
+				res, err := store.Take(ctx, "k", policy)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !res.Allowed || res.Remaining != 1-i {
+					t.Fatalf("take %d: unexpected result %+v", i, res)
+				}
+			}
+
+			res, err := store.Take(ctx, "k", policy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Allowed || res.RetryAfter <= 0 || res.RetryAfter > 30*time.Minute {
+				t.Fatalf("expected to be limited, got %+v", res)
+			}
+
+			if res, _ := store.Take(ctx, "other", policy); !res.Allowed {
+				t.Fatal("keys should have separate buckets")
+			}
+
+			purged, err := db.PurgeRateLimitBuckets(ctx, time.Now().Add(time.Minute))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if purged != 2 {
+				t.Fatalf("expected 2 buckets purged, got %d", purged)
+			}
+			if res, _ := store.Take(ctx, "k", policy); !res.Allowed {
+				t.Fatal("expected a fresh bucket after purging")
+			}
+		})
+	}
+}
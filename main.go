@@ -1,84 +1,388 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"database/sql"
-	"log"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log/slog"
 	"net/http"
 	"os"
-	"sync/atomic"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/ihyaulhaq/go-server/internal/api"
+	"github.com/ihyaulhaq/go-server/internal/audit"
+	"github.com/ihyaulhaq/go-server/internal/certs"
+	"github.com/ihyaulhaq/go-server/internal/config"
 	"github.com/ihyaulhaq/go-server/internal/database"
+	"github.com/ihyaulhaq/go-server/internal/database/sqlitedb"
+	"github.com/ihyaulhaq/go-server/internal/health"
+	"github.com/ihyaulhaq/go-server/internal/metrics"
+	"github.com/ihyaulhaq/go-server/internal/migrate"
+	"github.com/ihyaulhaq/go-server/internal/pubsub"
+	"github.com/ihyaulhaq/go-server/internal/ratelimit"
+	"github.com/ihyaulhaq/go-server/internal/tracing"
+	"github.com/ihyaulhaq/go-server/web"
 	"github.com/joho/godotenv"
 
 	_ "github.com/lib/pq"
 )
 
+// certPollInterval is how often the TLS certificate files are checked for
+// renewals; SIGHUP reloads them immediately.
+const certPollInterval = 30 * time.Second
+
+// eventBacklog is how many chirp events are kept for streams resuming
+// with Last-Event-ID.
+const eventBacklog = 1024
+
 func main() {
-	err := godotenv.Load()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stdout, nil)))
+
+	// .env is a local development convenience; containers pass real env vars.
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		fatal("error loading env file", err)
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+
+	cfg, err := config.Load(os.Args[1:], os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fatal("invalid configuration", err)
+	}
+
+	if cfg.PrintConfig {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(cfg.Redacted()); err != nil {
+			fatal("error printing configuration", err)
+		}
+		return
+	}
+
+	db, err := openDatabase(cfg)
 	if err != nil {
-		log.Fatal("Error loading env file")
+		fatal("error opening database", err)
 	}
 
-	const filepathRoot = "."
-	const port = "8080"
+	if cfg.AutoMigrate {
+		if err := migrate.Up(context.Background(), db, cfg.DBDriver); err != nil {
+			fatal("error applying migrations", err)
+		}
+	}
 
-	dbURL := os.Getenv("DB_URL")
-	enviroment := os.Getenv("PLATFORM")
-	db, err := sql.Open("postgres", dbURL)
-	jwtKey := os.Getenv("SECRET")
-	polka_key := os.Getenv("POLKA_KEY")
+	if err := migrate.CheckCompatible(context.Background(), db, cfg.DBDriver); err != nil {
+		fatal("refusing to serve", err)
+	}
 
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:    cfg.TracesExporter,
+		ServiceName: cfg.ServiceName,
+	})
 	if err != nil {
-		log.Fatal(err)
+		fatal("error setting up tracing", err)
 	}
-	dbQueries := database.New(db)
 
-	apiCfg := api.ApiConfig{
-		FileserverHits: atomic.Int32{},
-		DB:             dbQueries,
-		Platform:       enviroment,
-		SecretKey:      jwtKey,
-		PolkaKey:       polka_key,
+	store := newStore(cfg, db)
+	auditor := audit.NewAsyncAuditor(audit.DBWriter{DB: store}, 1024)
+
+	// Validate has already checked the spec.
+	rateLimits, _ := ratelimit.ParsePolicies(cfg.RateLimits)
+
+	security := api.SecurityPolicy{
+		ContentSecurityPolicy: cfg.ContentSecurityPolicy,
+		ReferrerPolicy:        cfg.ReferrerPolicy,
+		HSTSMaxAge:            cfg.HSTSMaxAge,
 	}
+	appSecurity := security
+	appSecurity.ContentSecurityPolicy = cfg.AppContentSecurityPolicy
+
+	apiCfg := api.ApiConfig{
+		Metrics:   metrics.New(db),
+		DB:        store,
+		Platform:  cfg.Platform,
+		SecretKey: cfg.Secret,
+		PolkaKey:  cfg.PolkaKey,
+		AdminKey:  cfg.AdminKey,
+		Auditor:   auditor,
+		SeedFile:  cfg.SeedFile,
+
+		ChirpRestoreWindow: cfg.ChirpRestoreWindow,
+		ChirpRetention:     cfg.ChirpRetention,
+
+		AccountDeletionDelay: cfg.AccountDeletionDelay,
+		IdempotencyTTL:       cfg.IdempotencyTTL,
 
-	fsHandler := apiCfg.MiddlewareMetricsInc(http.StripPrefix("/app", http.FileServer(http.Dir(filepathRoot))))
+		HealthCheckers: []health.Checker{
+			health.DatabaseChecker(db),
+			health.MigrationChecker(db, migrate.LatestVersion(cfg.DBDriver)),
+		},
+		HealthCheckTimeout: cfg.HealthCheckTimeout,
 
-	mux := http.NewServeMux()
+		RateLimiter: newRateLimiter(cfg, store),
+		RateLimits:  rateLimits,
 
-	mux.Handle("/app/", fsHandler)
+		CORS: api.CORSPolicy{
+			AllowedOrigins:   config.SplitList(cfg.CORSAllowedOrigins),
+			AllowedMethods:   config.SplitList(cfg.CORSAllowedMethods),
+			AllowedHeaders:   config.SplitList(cfg.CORSAllowedHeaders),
+			AllowCredentials: cfg.CORSAllowCredentials,
+			MaxAge:           cfg.CORSMaxAge,
+		},
+		Security: security,
+		RouteSecurity: map[string]api.SecurityPolicy{
+			"GET /app/": appSecurity,
+		},
+		EventHub: pubsub.NewHub(eventBacklog),
 
-	mux.HandleFunc("GET /api/healthz", api.HandlerReadiness)
+		RequireClientCert: cfg.TLSClientCAFile != "",
+	}
 
-	mux.HandleFunc("GET /admin/metrics", apiCfg.HandlerMetrics)
+	site, err := newStaticSite(cfg)
+	if err != nil {
+		fatal("error loading web UI", err)
+	}
 
-	mux.HandleFunc("POST /admin/reset", apiCfg.HandlerReset)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
-	mux.HandleFunc("POST /api/users", apiCfg.HandleCreateUser)
-	mux.HandleFunc("POST /api/login", apiCfg.HandleLogin)
-	mux.HandleFunc("POST /api/refresh", apiCfg.HandleRefreshToken)
-	mux.HandleFunc("POST /api/revoke", apiCfg.HandleRevokeToken)
-	mux.Handle(
-		"PUT /api/users",
-		apiCfg.ProtectedFunc(apiCfg.HandleEditUser),
-	)
+	apiCfg.StartPurger(ctx, time.Hour)
+	startEventPublisher(ctx, cfg, db, &apiCfg)
 
-	mux.Handle("POST /api/chirps",
-		apiCfg.ProtectedFunc(apiCfg.HandleCreateChirps),
-	)
-	mux.HandleFunc("GET /api/chirps", apiCfg.HandleGetChirps)
-	mux.HandleFunc("GET /api/chirps/{id}", apiCfg.HandleGetChirp)
-	mux.Handle(
-		"DELETE /api/chirps/{id}",
-		apiCfg.ProtectedFunc(apiCfg.HandleDeleteChirp),
-	)
+	var tlsConfig *tls.Config
+	if cfg.TLSEnabled() {
+		var reloader *certs.Reloader
+		reloader, tlsConfig, err = newTLSConfig(cfg)
+		if err != nil {
+			fatal("error loading TLS certificate", err)
+		}
+		go reloader.Watch(ctx, certPollInterval)
+		go reloadOnHangup(ctx, reloader)
+	}
 
-	mux.HandleFunc("POST /api/polka/webhooks", apiCfg.HandleUpgradeUserToChirpyRed)
 	srv := &http.Server{
-		Addr:    ":" + port,
-		Handler: mux,
+		Addr:              ":" + cfg.Port,
+		Handler:           api.MiddlewareTracing(api.MiddlewareLogging(apiCfg.MiddlewareInstrument(api.RecordRoute(apiCfg.Routes(site))))),
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+		TLSConfig:         tlsConfig,
+	}
+	// Event streams never go idle, so end them for Shutdown to finish.
+	srv.RegisterOnShutdown(apiCfg.EventHub.Close)
+
+	serveErr := make(chan error, 2)
+	go func() {
+		slog.Info("serving", "file_root", cfg.FileRoot, "port", cfg.Port, "tls", tlsConfig != nil)
+		if tlsConfig != nil {
+			// The certificate comes from TLSConfig.GetCertificate.
+			serveErr <- srv.ListenAndServeTLS("", "")
+			return
+		}
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	var redirectSrv *http.Server
+	if cfg.HTTPRedirectPort != "" {
+		redirectSrv = &http.Server{
+			Addr:              ":" + cfg.HTTPRedirectPort,
+			Handler:           api.HTTPSRedirect(cfg.Port),
+			ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+			ReadTimeout:       cfg.ReadTimeout,
+			WriteTimeout:      cfg.WriteTimeout,
+			IdleTimeout:       cfg.IdleTimeout,
+			MaxHeaderBytes:    cfg.MaxHeaderBytes,
+		}
+		go func() {
+			slog.Info("redirecting http to https", "port", cfg.HTTPRedirectPort)
+			serveErr <- redirectSrv.ListenAndServe()
+		}()
+	}
+
+	select {
+	case err := <-serveErr:
+		fatal("error serving http", err)
+	case <-ctx.Done():
+	}
+
+	// Restore default signal handling, so a second signal exits at once.
+	stop()
+
+	// Fail readiness first and keep serving for the drain delay, so load
+	// balancers see it and stop routing here. Then let in-flight requests
+	// finish before tearing down their dependencies.
+	apiCfg.StartDraining()
+	slog.Info("shutting down", "drain_delay", cfg.ShutdownDrainDelay.String(), "drain_timeout", cfg.ShutdownTimeout.String())
+	time.Sleep(cfg.ShutdownDrainDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if redirectSrv != nil {
+		if err := redirectSrv.Shutdown(shutdownCtx); err != nil {
+			slog.Error("error draining http redirect server", "error", err)
+		}
+	}
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Error("error draining http server", "error", err)
+	}
+
+	if err := auditor.Close(shutdownCtx); err != nil {
+		slog.Error("error flushing audit events", "error", err)
+	}
+
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Error("error flushing traces", "error", err)
+	}
+
+	if err := db.Close(); err != nil {
+		slog.Error("error closing database", "error", err)
 	}
 
-	log.Printf("Serving files from %s on port: %s\n", filepathRoot, port)
-	log.Fatal(srv.ListenAndServe())
+	slog.Info("shutdown complete")
+}
+
+// runMigrate implements "chirpy migrate up|down|status|redo [flags]".
+func runMigrate(args []string) {
+	if len(args) == 0 || !slices.Contains(migrate.Commands, args[0]) {
+		fmt.Fprintf(os.Stderr, "usage: %s migrate %s [flags]\n", os.Args[0], strings.Join(migrate.Commands, "|"))
+		os.Exit(2)
+	}
+
+	cfg, err := config.LoadUnvalidated(args[1:], os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fatal("invalid configuration", err)
+	}
+
+	if err := cfg.ValidateDatabase(); err != nil {
+		fatal("invalid configuration", err)
+	}
+
+	db, err := openDatabase(cfg)
+	if err != nil {
+		fatal("error opening database", err)
+	}
+	defer db.Close()
+
+	if err := migrate.Run(context.Background(), db, cfg.DBDriver, args[0], os.Stdout); err != nil {
+		fatal("migrate "+args[0]+" failed", err)
+	}
+}
+
+func openDatabase(cfg *config.Config) (*sql.DB, error) {
+	if cfg.DBDriver == database.DriverSQLite {
+		return sqlitedb.Open(cfg.DBURL)
+	}
+	return sql.Open(database.DriverPostgres, cfg.DBURL)
+}
+
+func newStore(cfg *config.Config, db *sql.DB) database.Store {
+	if cfg.DBDriver == database.DriverSQLite {
+		return sqlitedb.NewStore(db)
+	}
+	return database.NewPostgresStore(db)
+}
+
+// newRateLimiter keeps buckets in the database when they must be shared
+// between replicas, and in process memory otherwise.
+func newRateLimiter(cfg *config.Config, store database.Store) ratelimit.Store {
+	if cfg.RateLimitStore == config.RateLimitStoreDatabase {
+		return ratelimit.DBStore{DB: store}
+	}
+	return ratelimit.NewMemoryStore()
+}
+
+// startEventPublisher sends chirp events through Postgres NOTIFY so
+// streams on every replica see them, and keeps the replica out of rotation
+// while it can't LISTEN. SQLite has a single replica, which publishes
+// straight to its hub.
+func startEventPublisher(ctx context.Context, cfg *config.Config, db *sql.DB, apiCfg *api.ApiConfig) {
+	if cfg.DBDriver == database.DriverSQLite {
+		apiCfg.Events = apiCfg.EventHub
+		return
+	}
+
+	notifier := &pubsub.PGNotifier{DB: database.NewTracedDBTX(db, "postgresql"), DSN: cfg.DBURL}
+	go notifier.Listen(ctx, apiCfg.EventHub)
+	apiCfg.Events = notifier
+	apiCfg.HealthCheckers = append(apiCfg.HealthCheckers, health.NewChecker("events", notifier.Ready))
+}
+
+// newStaticSite serves the embedded web UI, or the working copy under
+// cfg.FileRoot so edits show up on reload during development.
+func newStaticSite(cfg *config.Config) (*api.StaticSite, error) {
+	if cfg.FileRoot != "" {
+		return api.NewStaticSite(web.DirFS(cfg.FileRoot), true)
+	}
+	return api.NewStaticSite(web.FS, false)
+}
+
+// newTLSConfig serves the certificate from cfg's files, reloading it as
+// they change. With a client CA, clients may present a certificate, which
+// the /admin/ routes then require.
+func newTLSConfig(cfg *config.Config) (*certs.Reloader, *tls.Config, error) {
+	reloader, err := certs.NewReloader(cfg.TLSCertFile, cfg.TLSKeyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.GetCertificate,
+	}
+	if cfg.TLSClientCAFile != "" {
+		pool, err := certs.LoadCertPool(cfg.TLSClientCAFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return reloader, tlsConfig, nil
+}
+
+// reloadOnHangup reloads the TLS certificate on SIGHUP until ctx is done.
+func reloadOnHangup(ctx context.Context, reloader *certs.Reloader) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+		}
+
+		if err := reloader.Reload(); err != nil {
+			slog.Error("error reloading TLS certificate", "error", err)
+			continue
+		}
+		slog.Info("reloaded TLS certificate", "not_after", reloader.NotAfter())
+	}
+}
+
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
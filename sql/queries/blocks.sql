@@ -0,0 +1,36 @@
+-- name: BlockUser :exec
+INSERT INTO user_blocks (blocker_id, blocked_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: UnblockUser :execrows
+DELETE FROM user_blocks
+WHERE blocker_id = $1
+  AND blocked_id = $2;
+
+-- name: IsBlocked :one
+SELECT EXISTS (
+  SELECT 1 FROM user_blocks
+  WHERE blocker_id = $1
+    AND blocked_id = $2
+);
+
+-- name: MuteUser :exec
+INSERT INTO user_mutes (muter_id, muted_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: UnmuteUser :execrows
+DELETE FROM user_mutes
+WHERE muter_id = $1
+  AND muted_id = $2;
+
+-- name: GetHiddenAuthorIDs :many
+SELECT muted_id FROM user_mutes
+WHERE muter_id = $1
+UNION
+SELECT blocked_id FROM user_blocks
+WHERE blocker_id = $1
+UNION
+SELECT blocker_id FROM user_blocks
+WHERE blocked_id = $1;
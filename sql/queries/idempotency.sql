@@ -0,0 +1,35 @@
+-- name: ClaimIdempotencyKey :one
+INSERT INTO idempotency_keys AS k (scope, key, request_hash, created_at, expires_at)
+VALUES (sqlc.arg('scope'), sqlc.arg('key'), sqlc.arg('request_hash'), now(), sqlc.arg('expires_at'))
+ON CONFLICT (scope, key) DO UPDATE SET
+    request_hash = EXCLUDED.request_hash,
+    status = NULL,
+    headers = NULL,
+    body = NULL,
+    created_at = EXCLUDED.created_at,
+    expires_at = EXCLUDED.expires_at
+WHERE k.expires_at < now()
+   OR (k.status IS NULL AND k.created_at < sqlc.arg('stale_before'))
+RETURNING *;
+
+-- name: GetIdempotencyKey :one
+SELECT * FROM idempotency_keys
+WHERE scope = $1
+  AND key = $2;
+
+-- name: CompleteIdempotencyKey :exec
+UPDATE idempotency_keys
+SET status = $3,
+    headers = $4,
+    body = $5
+WHERE scope = $1
+  AND key = $2;
+
+-- name: DeleteIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE scope = $1
+  AND key = $2;
+
+-- name: PurgeIdempotencyKeys :execrows
+DELETE FROM idempotency_keys
+WHERE expires_at < $1;
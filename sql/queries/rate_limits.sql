@@ -0,0 +1,16 @@
+-- name: TakeRateLimitToken :one
+INSERT INTO rate_limit_buckets AS b (key, tokens, allowed, updated_at)
+VALUES (sqlc.arg('key'), sqlc.arg('capacity')::float8 - 1, TRUE, now())
+ON CONFLICT (key) DO UPDATE SET
+    tokens = CASE
+        WHEN LEAST(sqlc.arg('capacity')::float8, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at)::float8 * sqlc.arg('refill_rate')::float8) >= 1
+        THEN LEAST(sqlc.arg('capacity')::float8, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at)::float8 * sqlc.arg('refill_rate')::float8) - 1
+        ELSE LEAST(sqlc.arg('capacity')::float8, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at)::float8 * sqlc.arg('refill_rate')::float8)
+    END,
+    allowed = LEAST(sqlc.arg('capacity')::float8, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at)::float8 * sqlc.arg('refill_rate')::float8) >= 1,
+    updated_at = now()
+RETURNING *;
+
+-- name: PurgeRateLimitBuckets :execrows
+DELETE FROM rate_limit_buckets
+WHERE updated_at < $1;
@@ -27,3 +27,10 @@ SET
 WHERE token = $1 
   AND revoked_at IS NULL;
 
+-- name: GetRefreshTokensByUser :many
+SELECT * FROM refresh_tokens
+WHERE user_id = $1
+ORDER BY created_at ASC;
+
+-- name: DeleteRefreshTokens :exec
+DELETE FROM refresh_tokens;
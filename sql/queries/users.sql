@@ -35,3 +35,95 @@ RETURNING id, created_at, updated_at, email, is_chirpy_red;
 
 -- name: DeleteUsers :exec
 DELETE FROM users;
+
+-- name: GetUserByID :one
+SELECT * FROM users WHERE id = $1;
+
+-- name: SuspendUser :one
+WITH revoked AS (
+  UPDATE refresh_tokens
+  SET revoked_at = now(),
+      updated_at = now()
+  WHERE user_id = $1
+    AND revoked_at IS NULL
+)
+UPDATE users
+SET suspended_until = $2,
+    suspension_reason = $3,
+    updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: UnsuspendUser :one
+UPDATE users
+SET suspended_until = NULL,
+    suspension_reason = NULL,
+    updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: BanUser :one
+WITH revoked AS (
+  UPDATE refresh_tokens
+  SET revoked_at = now(),
+      updated_at = now()
+  WHERE user_id = $1
+    AND revoked_at IS NULL
+)
+UPDATE users
+SET banned_at = now(),
+    ban_reason = $2,
+    updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: UnbanUser :one
+UPDATE users
+SET banned_at = NULL,
+    ban_reason = NULL,
+    updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: ScheduleUserDeletion :one
+WITH revoked AS (
+  UPDATE refresh_tokens
+  SET revoked_at = now(),
+      updated_at = now()
+  WHERE user_id = $1
+    AND revoked_at IS NULL
+)
+UPDATE users
+SET deletion_scheduled_at = $2,
+    updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: CancelUserDeletion :one
+UPDATE users
+SET deletion_scheduled_at = NULL,
+    updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: PurgeScheduledUserDeletions :execrows
+DELETE FROM users
+WHERE deletion_scheduled_at < $1;
+
+-- name: InsertUser :one
+INSERT INTO users (
+  id,
+  created_at,
+  updated_at,
+  email,
+  hashed_password,
+  is_chirpy_red
+) VALUES (
+  $1,
+  $2,
+  $2,
+  $3,
+  $4,
+  $5
+)
+RETURNING *;
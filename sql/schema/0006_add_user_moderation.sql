@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN suspended_until TIMESTAMP WITH TIME ZONE,
+ADD COLUMN suspension_reason TEXT,
+ADD COLUMN banned_at TIMESTAMP WITH TIME ZONE,
+ADD COLUMN ban_reason TEXT;
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN ban_reason,
+DROP COLUMN banned_at,
+DROP COLUMN suspension_reason,
+DROP COLUMN suspended_until;
@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_chirps_deleted_at ON chirps(deleted_at);
+
+-- +goose Down
+DROP INDEX idx_chirps_deleted_at;
+
+ALTER TABLE chirps
+DROP COLUMN deleted_at;
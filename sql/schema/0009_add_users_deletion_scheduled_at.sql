@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN deletion_scheduled_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_users_deletion_scheduled_at ON users(deletion_scheduled_at);
+
+-- +goose Down
+DROP INDEX idx_users_deletion_scheduled_at;
+
+ALTER TABLE users
+DROP COLUMN deletion_scheduled_at;
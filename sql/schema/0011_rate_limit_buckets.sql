@@ -0,0 +1,16 @@
+-- +goose Up
+-- One token bucket per rate-limit key, shared by every replica.
+-- TakeRateLimitToken refills and takes from a bucket in a single upsert, so
+-- the row lock serialises concurrent requests; allowed records whether that
+-- take succeeded so it can be read back from RETURNING.
+CREATE TABLE rate_limit_buckets (
+    key TEXT PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    allowed BOOLEAN NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX idx_rate_limit_buckets_updated_at ON rate_limit_buckets(updated_at);
+
+-- +goose Down
+DROP TABLE rate_limit_buckets;
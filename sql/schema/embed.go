@@ -0,0 +1,8 @@
+// Package schema embeds the goose migrations in this directory so the
+// binary can apply them without the source tree.
+package schema
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
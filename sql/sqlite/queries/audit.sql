@@ -0,0 +1,25 @@
+-- name: CreateAuditEvent :exec
+INSERT INTO audit_events (
+  created_at,
+  actor_id,
+  action,
+  target,
+  ip,
+  user_agent
+) VALUES (
+  ?,
+  ?,
+  ?,
+  ?,
+  ?,
+  ?
+);
+
+-- name: ListAuditEvents :many
+SELECT * FROM audit_events
+WHERE (actor_id = sqlc.narg('actor_id') OR sqlc.narg('actor_id') IS NULL)
+  AND (action = sqlc.narg('action') OR sqlc.narg('action') IS NULL)
+  AND (created_at >= sqlc.narg('since') OR sqlc.narg('since') IS NULL)
+  AND (created_at < sqlc.narg('until') OR sqlc.narg('until') IS NULL)
+ORDER BY id DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
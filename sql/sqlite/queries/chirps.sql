@@ -0,0 +1,105 @@
+-- name: CreateChirps :one
+INSERT INTO chirps (
+  created_at,
+  updated_at,
+  body,
+  user_id
+) VALUES (
+  CAST(unixepoch('subsec') * 1000 AS INTEGER) * 1000000,
+  CAST(unixepoch('subsec') * 1000 AS INTEGER) * 1000000,
+  ?,
+  ?
+)
+RETURNING *;
+
+-- name: GetChirps :many
+SELECT * FROM chirps
+WHERE deleted_at IS NULL
+ORDER BY created_at ASC, rowid ASC;
+
+-- name: GetChirp :one
+SELECT * FROM chirps
+WHERE id = ?
+  AND deleted_at IS NULL
+LIMIT 1;
+
+-- name: GetChirpByAuthor :many
+SELECT * FROM chirps
+WHERE user_id = ?
+  AND deleted_at IS NULL
+ORDER BY created_at ASC, rowid ASC;
+
+-- name: DeleteChirp :execrows
+UPDATE chirps
+SET deleted_at = CAST(unixepoch('subsec') * 1000 AS INTEGER) * 1000000
+WHERE id = sqlc.arg('id')
+  AND user_id = sqlc.arg('user_id')
+  AND deleted_at IS NULL
+  AND (updated_at = sqlc.narg('updated_at') OR sqlc.narg('updated_at') IS NULL);
+
+-- name: RestoreChirp :one
+UPDATE chirps
+SET deleted_at = NULL
+WHERE id = ?
+  AND user_id = ?
+  AND deleted_at > ?
+RETURNING *;
+
+-- name: PurgeDeletedChirps :execrows
+DELETE FROM chirps
+WHERE deleted_at < ?;
+
+-- name: DeleteChirps :exec
+DELETE FROM chirps;
+
+-- name: GetChirpsForViewer :many
+SELECT * FROM chirps
+WHERE deleted_at IS NULL
+AND NOT EXISTS (
+  SELECT 1 FROM user_mutes
+  WHERE user_mutes.muter_id = ?1
+    AND user_mutes.muted_id = chirps.user_id
+)
+AND NOT EXISTS (
+  SELECT 1 FROM user_blocks
+  WHERE (user_blocks.blocker_id = ?1 AND user_blocks.blocked_id = chirps.user_id)
+     OR (user_blocks.blocker_id = chirps.user_id AND user_blocks.blocked_id = ?1)
+)
+ORDER BY created_at ASC, rowid ASC;
+
+-- name: GetChirpByAuthorForViewer :many
+SELECT * FROM chirps
+WHERE user_id = ?1
+AND deleted_at IS NULL
+AND NOT EXISTS (
+  SELECT 1 FROM user_mutes
+  WHERE user_mutes.muter_id = ?2
+    AND user_mutes.muted_id = chirps.user_id
+)
+AND NOT EXISTS (
+  SELECT 1 FROM user_blocks
+  WHERE (user_blocks.blocker_id = ?2 AND user_blocks.blocked_id = chirps.user_id)
+     OR (user_blocks.blocker_id = chirps.user_id AND user_blocks.blocked_id = ?2)
+)
+ORDER BY created_at ASC, rowid ASC;
+
+-- name: ExportChirpsByAuthor :many
+SELECT * FROM chirps
+WHERE user_id = ?
+ORDER BY created_at ASC, rowid ASC;
+
+-- name: InsertChirp :one
+INSERT INTO chirps (
+  id,
+  created_at,
+  updated_at,
+  body,
+  user_id
+) VALUES (
+  ?1,
+  ?2,
+  ?2,
+  ?3,
+  ?4
+)
+RETURNING *;
@@ -0,0 +1,16 @@
+-- name: TakeRateLimitToken :one
+INSERT INTO rate_limit_buckets AS b (key, tokens, allowed, updated_at)
+VALUES (sqlc.arg('key'), sqlc.arg('capacity') - 1, TRUE, CAST(unixepoch('subsec') * 1000 AS INTEGER) * 1000000)
+ON CONFLICT (key) DO UPDATE SET
+    tokens = CASE
+        WHEN min(sqlc.arg('capacity'), b.tokens + (CAST(unixepoch('subsec') * 1000 AS INTEGER) * 1000000 - b.updated_at) / 1e9 * sqlc.arg('refill_rate')) >= 1
+        THEN min(sqlc.arg('capacity'), b.tokens + (CAST(unixepoch('subsec') * 1000 AS INTEGER) * 1000000 - b.updated_at) / 1e9 * sqlc.arg('refill_rate')) - 1
+        ELSE min(sqlc.arg('capacity'), b.tokens + (CAST(unixepoch('subsec') * 1000 AS INTEGER) * 1000000 - b.updated_at) / 1e9 * sqlc.arg('refill_rate'))
+    END,
+    allowed = min(sqlc.arg('capacity'), b.tokens + (CAST(unixepoch('subsec') * 1000 AS INTEGER) * 1000000 - b.updated_at) / 1e9 * sqlc.arg('refill_rate')) >= 1,
+    updated_at = CAST(unixepoch('subsec') * 1000 AS INTEGER) * 1000000
+RETURNING *;
+
+-- name: PurgeRateLimitBuckets :execrows
+DELETE FROM rate_limit_buckets
+WHERE updated_at < ?;
@@ -0,0 +1,40 @@
+-- name: CreateRefreshToken :one
+INSERT INTO refresh_tokens (
+  token,
+  user_id,
+  expires_at
+) VALUES (
+  ?,
+  ?,
+  ?
+)
+RETURNING *;
+
+-- name: GetRefreshToken :one
+SELECT token, created_at, updated_at, user_id, expires_at, revoked_at
+FROM refresh_tokens
+WHERE token = ?;
+
+-- name: RevokeRefreshToken :exec
+UPDATE refresh_tokens
+SET
+  revoked_at = CAST(unixepoch('subsec') * 1000 AS INTEGER) * 1000000,
+  updated_at = CAST(unixepoch('subsec') * 1000 AS INTEGER) * 1000000
+WHERE token = ?
+  AND revoked_at IS NULL;
+
+-- name: RevokeUserRefreshTokens :exec
+UPDATE refresh_tokens
+SET
+  revoked_at = CAST(unixepoch('subsec') * 1000 AS INTEGER) * 1000000,
+  updated_at = CAST(unixepoch('subsec') * 1000 AS INTEGER) * 1000000
+WHERE user_id = ?
+  AND revoked_at IS NULL;
+
+-- name: GetRefreshTokensByUser :many
+SELECT * FROM refresh_tokens
+WHERE user_id = ?
+ORDER BY created_at ASC, rowid ASC;
+
+-- name: DeleteRefreshTokens :exec
+DELETE FROM refresh_tokens;
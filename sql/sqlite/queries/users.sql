@@ -0,0 +1,106 @@
+-- name: CreateUser :one
+INSERT INTO users (
+  created_at,
+  updated_at,
+  email,
+  hashed_password
+) VALUES (
+  CAST(unixepoch('subsec') * 1000 AS INTEGER) * 1000000,
+  CAST(unixepoch('subsec') * 1000 AS INTEGER) * 1000000,
+  ?,
+  ?
+)
+RETURNING *;
+
+-- name: GetUserByEmail :one
+SELECT * FROM users WHERE email = ?;
+
+-- name: UpdateUser :one
+UPDATE users
+SET
+  email = ?,
+  hashed_password = ?,
+  updated_at = CAST(unixepoch('subsec') * 1000 AS INTEGER) * 1000000
+WHERE id = ?
+RETURNING id, created_at, updated_at, email, is_chirpy_red;
+
+-- name: UpgradeUserToChirpyRed :one
+UPDATE users
+SET is_chirpy_red = TRUE,
+    updated_at = CAST(unixepoch('subsec') * 1000 AS INTEGER) * 1000000
+WHERE id = ?
+RETURNING id, created_at, updated_at, email, is_chirpy_red;
+
+-- name: DeleteUsers :exec
+DELETE FROM users;
+
+-- name: GetUserByID :one
+SELECT * FROM users WHERE id = ?;
+
+-- name: SuspendUser :one
+UPDATE users
+SET suspended_until = ?2,
+    suspension_reason = ?3,
+    updated_at = CAST(unixepoch('subsec') * 1000 AS INTEGER) * 1000000
+WHERE id = ?1
+RETURNING *;
+
+-- name: UnsuspendUser :one
+UPDATE users
+SET suspended_until = NULL,
+    suspension_reason = NULL,
+    updated_at = CAST(unixepoch('subsec') * 1000 AS INTEGER) * 1000000
+WHERE id = ?
+RETURNING *;
+
+-- name: BanUser :one
+UPDATE users
+SET banned_at = CAST(unixepoch('subsec') * 1000 AS INTEGER) * 1000000,
+    ban_reason = ?2,
+    updated_at = CAST(unixepoch('subsec') * 1000 AS INTEGER) * 1000000
+WHERE id = ?1
+RETURNING *;
+
+-- name: UnbanUser :one
+UPDATE users
+SET banned_at = NULL,
+    ban_reason = NULL,
+    updated_at = CAST(unixepoch('subsec') * 1000 AS INTEGER) * 1000000
+WHERE id = ?
+RETURNING *;
+
+-- name: ScheduleUserDeletion :one
+UPDATE users
+SET deletion_scheduled_at = ?2,
+    updated_at = CAST(unixepoch('subsec') * 1000 AS INTEGER) * 1000000
+WHERE id = ?1
+RETURNING *;
+
+-- name: CancelUserDeletion :one
+UPDATE users
+SET deletion_scheduled_at = NULL,
+    updated_at = CAST(unixepoch('subsec') * 1000 AS INTEGER) * 1000000
+WHERE id = ?
+RETURNING *;
+
+-- name: PurgeScheduledUserDeletions :execrows
+DELETE FROM users
+WHERE deletion_scheduled_at < ?;
+
+-- name: InsertUser :one
+INSERT INTO users (
+  id,
+  created_at,
+  updated_at,
+  email,
+  hashed_password,
+  is_chirpy_red
+) VALUES (
+  ?1,
+  ?2,
+  ?2,
+  ?3,
+  ?4,
+  ?5
+)
+RETURNING *;
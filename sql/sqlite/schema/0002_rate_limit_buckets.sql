@@ -0,0 +1,14 @@
+-- SQLite equivalent of sql/schema/0011_rate_limit_buckets.sql.
+
+-- +goose Up
+CREATE TABLE rate_limit_buckets (
+    key TEXT PRIMARY KEY NOT NULL,
+    tokens REAL NOT NULL,
+    allowed BOOLEAN NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_rate_limit_buckets_updated_at ON rate_limit_buckets(updated_at);
+
+-- +goose Down
+DROP TABLE rate_limit_buckets;
@@ -0,0 +1,43 @@
+// Package web holds the Chirpy web UI served under /app/. The files are
+// embedded so the binary serves exactly this allowlist and never the
+// working directory it happens to run from.
+package web
+
+import (
+	"embed"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+//go:embed index.html assets
+var FS embed.FS
+
+// allowlist must match the go:embed patterns above.
+var allowlist = []string{"index.html", "assets"}
+
+// DirFS serves the same allowlist from dir on disk, so edits show up
+// without a rebuild during development.
+func DirFS(dir string) fs.FS {
+	return allowlistFS{os.DirFS(dir)}
+}
+
+type allowlistFS struct {
+	fsys fs.FS
+}
+
+func (a allowlistFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) || !allowed(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return a.fsys.Open(name)
+}
+
+func allowed(name string) bool {
+	for _, entry := range allowlist {
+		if name == entry || strings.HasPrefix(name, entry+"/") {
+			return true
+		}
+	}
+	return false
+}